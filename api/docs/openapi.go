@@ -0,0 +1,18 @@
+package docs
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+//goland:noinspection GoUnusedExportedFunction
+func OpenAPIHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Add("Cache-Control", "max-age=0, s-maxage=86400")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(spec)
+}
@@ -0,0 +1,26 @@
+package docs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSpecDocumentsEndpoints(t *testing.T) {
+	var document struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &document); err != nil {
+		t.Fatal(err)
+	}
+
+	if document.OpenAPI == "" {
+		t.Error("missing openapi version")
+	}
+
+	for _, path := range []string{"/api/humblebundle/md", "/api/woksoflife/md"} {
+		if _, ok := document.Paths[path]; !ok {
+			t.Errorf("path %s is not documented", path)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package health
+
+import (
+	"net/http"
+
+	"humblebundle-scraper/internal"
+)
+
+//goland:noinspection GoUnusedExportedFunction
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	internal.Healthz(w, r)
+}
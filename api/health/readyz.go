@@ -0,0 +1,18 @@
+package health
+
+import (
+	"net/http"
+
+	"humblebundle-scraper/internal"
+)
+
+//goland:noinspection GoUnusedExportedFunction
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	NewReadyzHandler(internal.DefaultApp())(w, r)
+}
+
+// NewReadyzHandler returns the readiness endpoint checking the
+// dependencies of app and reporting its circuit breakers.
+func NewReadyzHandler(app *internal.App) http.HandlerFunc {
+	return internal.Readyz(app.ReadinessChecks(), app.Breaker)
+}
@@ -0,0 +1,111 @@
+package humblebundle
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"humblebundle-scraper/internal"
+)
+
+//goland:noinspection GoUnusedExportedFunction
+func FeedHandler(w http.ResponseWriter, r *http.Request) {
+	NewFeedHandler(internal.DefaultApp())(w, r)
+}
+
+// NewFeedHandler returns the Atom feed of the bundles on sale, served with
+// the components of app. Feed readers cannot send the X-API-Key header, so
+// the key is also accepted as the apiKey query param.
+func NewFeedHandler(app *internal.App) http.HandlerFunc {
+	return internal.APIKeyFromQuery(
+		app.Handler(
+			"humblebundle.feed",
+			func(w http.ResponseWriter, r *http.Request) {
+				handleFeed(app, w, r)
+			},
+		),
+	)
+}
+
+func handleFeed(app *internal.App, w http.ResponseWriter, r *http.Request) {
+	browserlessToken := internal.BrowserlessToken(r)
+
+	if !app.CanFetch(browserlessToken) {
+		internal.WriteProblem(
+			w,
+			http.StatusBadRequest,
+			"missing_params",
+			"the query param 'browserlessToken' is required",
+		)
+		return
+	}
+
+	category := r.URL.Query().Get("category")
+
+	if err := internal.CheckBundleCategory(category); err != nil {
+		internal.WriteError(w, err)
+		return
+	}
+
+	// every category is listed on the same page, cached once
+	bundles, cacheStatus, err := internal.Cached(
+		r.Context(),
+		app.Cache,
+		"bundles",
+		browserlessToken,
+		false,
+		func(ctx context.Context) ([]internal.BundleListing, error) {
+			return internal.ListBundles(ctx, app.Fetcher(browserlessToken), "")
+		},
+	)
+	if err != nil {
+		internal.LoggerFrom(r.Context()).Error(
+			"listing bundles failed",
+			"category", category,
+			"error", err,
+		)
+		internal.WriteError(w, err)
+		return
+	}
+
+	bundles = internal.FilterBundles(bundles, category)
+
+	title := "Humble Bundles"
+	if category != "" {
+		title = "Humble " + category + " bundles"
+	}
+
+	query := url.Values{}
+	if category != "" {
+		query.Set("category", category)
+	}
+	id := feedURL(r, query)
+
+	// subscribing through the self link needs the credentials of the request
+	for _, param := range []string{"browserlessToken", "apiKey"} {
+		if value := r.URL.Query().Get(param); value != "" {
+			query.Set(param, value)
+		}
+	}
+
+	feed, err := internal.BundlesFeed(title, id, feedURL(r, query), bundles)
+	if err != nil {
+		internal.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Header().Add("Cache-Control", app.CacheControl(3600))
+	w.Header().Set("X-Cache", string(cacheStatus))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(feed)
+}
+
+func feedURL(r *http.Request, query url.Values) string {
+	feedURL := "https://" + r.Host + r.URL.Path
+	if len(query) > 0 {
+		feedURL += "?" + query.Encode()
+	}
+
+	return feedURL
+}
@@ -0,0 +1,126 @@
+package humblebundle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"humblebundle-scraper/internal"
+)
+
+//goland:noinspection GoUnusedExportedFunction
+func ListHandler(w http.ResponseWriter, r *http.Request) {
+	NewListHandler(internal.DefaultApp())(w, r)
+}
+
+// NewListHandler returns the endpoint listing the bundles on sale, served
+// with the components of app.
+func NewListHandler(app *internal.App) http.HandlerFunc {
+	return app.Handler(
+		"humblebundle.list",
+		func(w http.ResponseWriter, r *http.Request) {
+			handleList(app, w, r)
+		},
+	)
+}
+
+func handleList(app *internal.App, w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	format, ok := internal.NegotiateFormat(r)
+	if !ok {
+		internal.WriteProblem(
+			w,
+			http.StatusNotAcceptable,
+			"unsupported_format",
+			"supported formats are text, json, xml and yaml",
+		)
+		return
+	}
+
+	browserlessToken := internal.BrowserlessToken(r)
+
+	if !app.CanFetch(browserlessToken) {
+		internal.WriteProblem(
+			w,
+			http.StatusBadRequest,
+			"missing_params",
+			"the query param 'browserlessToken' is required",
+		)
+		return
+	}
+
+	category := queryParams.Get("category")
+
+	if err := internal.CheckBundleCategory(category); err != nil {
+		internal.WriteError(w, err)
+		return
+	}
+
+	// every category is listed on the same page, cached once
+	bundles, cacheStatus, err := internal.Cached(
+		r.Context(),
+		app.Cache,
+		"bundles",
+		browserlessToken,
+		queryParams.Get("refresh") == "true",
+		func(ctx context.Context) ([]internal.BundleListing, error) {
+			return internal.ListBundles(ctx, app.Fetcher(browserlessToken), "")
+		},
+	)
+	if err != nil {
+		internal.LoggerFrom(r.Context()).Error(
+			"listing bundles failed",
+			"category", category,
+			"error", err,
+		)
+		internal.WriteError(w, err)
+		return
+	}
+
+	bundles = internal.FilterBundles(bundles, category)
+
+	var response []byte
+	if format == internal.FormatText {
+		response = []byte(renderBundles(bundles))
+	} else if response, err = internal.Marshal(
+		format,
+		internal.BundleIndex{Bundles: bundles},
+	); err != nil {
+		internal.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", internal.ContentType(format))
+	w.Header().Add("Cache-Control", app.CacheControl(3600))
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("X-Cache", string(cacheStatus))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func renderBundles(bundles []internal.BundleListing) string {
+	lines := make([]string, len(bundles))
+	for i, bundle := range bundles {
+		items := ""
+		if bundle.Items > 0 {
+			items = fmt.Sprintf("%d items, ", bundle.Items)
+		}
+
+		lines[i] = fmt.Sprintf(
+			"- %s (%s%s, ends %s): %s",
+			bundle.Name,
+			items,
+			bundle.Category,
+			bundle.EndsAt.Format("2006-01-02"),
+			bundle.URL,
+		)
+	}
+
+	return fmt.Sprintf(
+		"Humble Bundles on sale (%d)\n\n%s",
+		len(bundles),
+		strings.Join(lines, "\n"),
+	)
+}
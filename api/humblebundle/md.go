@@ -1,6 +1,7 @@
 package humblebundle
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -10,36 +11,119 @@ import (
 
 //goland:noinspection GoUnusedExportedFunction
 func Handler(w http.ResponseWriter, r *http.Request) {
+	NewHandler(internal.DefaultApp())(w, r)
+}
+
+// NewHandler returns the bundle endpoint served with the components of app.
+func NewHandler(app *internal.App) http.HandlerFunc {
+	return app.Handler(
+		"humblebundle",
+		func(w http.ResponseWriter, r *http.Request) {
+			handle(app, w, r)
+		},
+	)
+}
+
+func handle(app *internal.App, w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
 
-	if !queryParams.Has("url") || !queryParams.Has("browserlessToken") {
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write([]byte("the query params 'url' and 'browserlessToken' are required"))
+	format, ok := internal.NegotiateFormat(r)
+	if !ok {
+		internal.WriteProblem(
+			w,
+			http.StatusNotAcceptable,
+			"unsupported_format",
+			"supported formats are text, json, xml and yaml",
+		)
+		return
+	}
+
+	browserlessToken := internal.BrowserlessToken(r)
+
+	if !queryParams.Has("url") || !app.CanFetch(browserlessToken) {
+		internal.WriteProblem(
+			w,
+			http.StatusBadRequest,
+			"missing_params",
+			"the query params 'url' and 'browserlessToken' are required",
+		)
 		return
 	}
 
 	url := queryParams.Get("url")
-	browserlessToken := queryParams.Get("browserlessToken")
+	if err := internal.ValidateURL(url); err != nil {
+		internal.WriteError(w, err)
+		return
+	}
 
-	bundle, err := internal.GetBundleData(
+	bundle, cacheStatus, err := internal.Cached(
+		r.Context(),
+		app.Cache,
+		"bundle:"+internal.NormalizeURL(url),
 		browserlessToken,
-		url,
+		queryParams.Get("refresh") == "true",
+		func(ctx context.Context) (internal.Bundle, error) {
+			fetcher := app.Fetcher(browserlessToken)
+			if !app.Config.WaybackFallback {
+				return internal.GetBundleData(ctx, fetcher, url)
+			}
+
+			bundle, snapshot, err := internal.WaybackFallback(
+				ctx,
+				fetcher,
+				url,
+				internal.GetBundleData,
+			)
+			bundle.Archived = snapshot
+			return bundle, err
+		},
 	)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_, _ = w.Write([]byte(err.Error()))
+		internal.LoggerFrom(r.Context()).Error(
+			"scraping bundle failed",
+			"url", url,
+			"error", err,
+		)
+		internal.WriteError(w, err)
 		return
 	}
 
-	response := fmt.Sprintf(
+	var response []byte
+	if format == internal.FormatText {
+		response = []byte(renderBundle(bundle))
+	} else if response, err = internal.Marshal(format, bundle); err != nil {
+		internal.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", internal.ContentType(format))
+	w.Header().Add("Cache-Control", app.CacheControl(86400))
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("X-Cache", string(cacheStatus))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func renderBundle(bundle internal.Bundle) string {
+	items := make([]string, len(bundle.Items))
+	for i, item := range bundle.Items {
+		items[i] = fmt.Sprintf("- %s", item)
+	}
+
+	rendered := fmt.Sprintf(
 		"Humble Bundle \"%s\" (%d items)\n\n%s",
 		bundle.Name,
 		len(bundle.Items),
-		strings.Join(bundle.Items, "\n"),
+		strings.Join(items, "\n"),
 	)
 
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.Header().Add("Cache-Control", "max-age=0, s-maxage=86400")
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte(response))
+	if bundle.Archived != nil {
+		rendered += fmt.Sprintf(
+			"\n\n> archived on %s: %s",
+			bundle.Archived.Timestamp.Format("2006-01-02"),
+			bundle.Archived.URL,
+		)
+	}
+
+	return rendered
 }
@@ -0,0 +1,18 @@
+package usage
+
+import (
+	"net/http"
+
+	"humblebundle-scraper/internal"
+)
+
+//goland:noinspection GoUnusedExportedFunction
+func BrowserlessHandler(w http.ResponseWriter, r *http.Request) {
+	NewBrowserlessHandler(internal.DefaultApp())(w, r)
+}
+
+// NewBrowserlessHandler returns the endpoint reporting the Browserless
+// units spent with each token this month.
+func NewBrowserlessHandler(app *internal.App) http.HandlerFunc {
+	return app.Handler("usage", app.Budget.ServeHTTP)
+}
@@ -1,6 +1,7 @@
 package woksoflife
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -37,19 +38,100 @@ func normalizeFractions(line string) string {
 
 //goland:noinspection GoUnusedExportedFunction
 func Handler(w http.ResponseWriter, r *http.Request) {
+	NewHandler(internal.DefaultApp())(w, r)
+}
+
+// NewHandler returns the recipe endpoint served with the components of app.
+func NewHandler(app *internal.App) http.HandlerFunc {
+	return app.Handler(
+		"woksoflife",
+		func(w http.ResponseWriter, r *http.Request) {
+			handle(app, w, r)
+		},
+	)
+}
+
+func handle(app *internal.App, w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
 
-	if !queryParams.Has("url") || !queryParams.Has("browserlessToken") {
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write([]byte("the query params 'url' and 'browserlessToken' are required"))
+	format, ok := internal.NegotiateFormat(r)
+	if !ok {
+		internal.WriteProblem(
+			w,
+			http.StatusNotAcceptable,
+			"unsupported_format",
+			"supported formats are text, json, xml and yaml",
+		)
+		return
+	}
+
+	browserlessToken := internal.BrowserlessToken(r)
+
+	if !queryParams.Has("url") || !app.CanFetch(browserlessToken) {
+		internal.WriteProblem(
+			w,
+			http.StatusBadRequest,
+			"missing_params",
+			"the query params 'url' and 'browserlessToken' are required",
+		)
 		return
 	}
 
 	url := queryParams.Get("url")
-	browserlessToken := queryParams.Get("browserlessToken")
+	if err := internal.ValidateURL(url); err != nil {
+		internal.WriteError(w, err)
+		return
+	}
+
+	recipe, cacheStatus, err := internal.Cached(
+		r.Context(),
+		app.Cache,
+		"recipe:"+internal.NormalizeURL(url),
+		browserlessToken,
+		queryParams.Get("refresh") == "true",
+		func(ctx context.Context) (internal.Recipe, error) {
+			fetcher := app.Fetcher(browserlessToken)
+			if !app.Config.WaybackFallback {
+				return internal.GetRecipe(ctx, fetcher, url)
+			}
+
+			recipe, snapshot, err := internal.WaybackFallback(
+				ctx,
+				fetcher,
+				url,
+				internal.GetRecipe,
+			)
+			recipe.Archived = snapshot
+			return recipe, err
+		},
+	)
+	if err != nil {
+		internal.LoggerFrom(r.Context()).Error(
+			"scraping recipe failed",
+			"url", url,
+			"error", err,
+		)
+		internal.WriteError(w, err)
+		return
+	}
 
-	recipe, _ := internal.GetRecipe(browserlessToken, url)
+	var response []byte
+	if format == internal.FormatText {
+		response = []byte(renderRecipe(url, recipe))
+	} else if response, err = internal.Marshal(format, recipe); err != nil {
+		internal.WriteError(w, err)
+		return
+	}
 
+	w.Header().Set("Content-Type", internal.ContentType(format))
+	w.Header().Add("Cache-Control", app.CacheControl(86400))
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("X-Cache", string(cacheStatus))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
+
+func renderRecipe(url string, recipe internal.Recipe) string {
 	response := strings.Builder{}
 	response.WriteString(
 		fmt.Sprintf(
@@ -86,8 +168,15 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		response.WriteString("\n> notes: " + recipe.Notes)
 	}
 
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.Header().Add("Cache-Control", "max-age=0, s-maxage=86400")
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte(response.String()))
+	if recipe.Archived != nil {
+		response.WriteString(
+			fmt.Sprintf(
+				"\n> archived on %s: %s",
+				recipe.Archived.Timestamp.Format("2006-01-02"),
+				recipe.Archived.URL,
+			),
+		)
+	}
+
+	return response.String()
 }
@@ -0,0 +1,106 @@
+// Command lambda serves the scraper functions on AWS Lambda, behind a
+// function URL or an API Gateway HTTP API (payload format 2.0).
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"humblebundle-scraper/internal"
+	"humblebundle-scraper/internal/config"
+	"humblebundle-scraper/server"
+)
+
+func main() {
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	app, err := internal.NewApp(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lambda.Start(serve(server.NewMux(app)))
+}
+
+type lambdaHandler func(
+	ctx context.Context,
+	event events.APIGatewayV2HTTPRequest,
+) (events.APIGatewayV2HTTPResponse, error)
+
+// serve adapts handler to Lambda HTTP events.
+func serve(handler http.Handler) lambdaHandler {
+	return func(
+		ctx context.Context,
+		event events.APIGatewayV2HTTPRequest,
+	) (events.APIGatewayV2HTTPResponse, error) {
+		body := []byte(event.Body)
+		if event.IsBase64Encoded {
+			decoded, err := base64.StdEncoding.DecodeString(event.Body)
+			if err != nil {
+				return events.APIGatewayV2HTTPResponse{}, err
+			}
+			body = decoded
+		}
+
+		target := event.RawPath
+		if event.RawQueryString != "" {
+			target += "?" + event.RawQueryString
+		}
+
+		req, err := http.NewRequestWithContext(
+			ctx,
+			event.RequestContext.HTTP.Method,
+			target,
+			strings.NewReader(string(body)),
+		)
+		if err != nil {
+			return events.APIGatewayV2HTTPResponse{}, err
+		}
+
+		for name, value := range event.Headers {
+			req.Header.Set(name, value)
+		}
+		if len(event.Cookies) > 0 {
+			req.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+		}
+		req.Host = event.RequestContext.DomainName
+		req.RemoteAddr = net.JoinHostPort(event.RequestContext.HTTP.SourceIP, "0")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		response := events.APIGatewayV2HTTPResponse{
+			StatusCode: recorder.Code,
+			Headers:    map[string]string{},
+		}
+		for name, values := range recorder.Header() {
+			if name == "Set-Cookie" {
+				response.Cookies = values
+				continue
+			}
+			response.Headers[name] = strings.Join(values, ", ")
+		}
+
+		if utf8.Valid(recorder.Body.Bytes()) {
+			response.Body = recorder.Body.String()
+		} else {
+			response.Body = base64.StdEncoding.EncodeToString(recorder.Body.Bytes())
+			response.IsBase64Encoded = true
+		}
+
+		return response, nil
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestServe(t *testing.T) {
+	handler := serve(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if r.URL.Path != "/api/humblebundle/md" ||
+					r.URL.Query().Get("url") != "https://example.com" ||
+					r.Header.Get("Accept") != "application/json" ||
+					r.RemoteAddr != "1.2.3.4:0" ||
+					string(body) != "payload" {
+					t.Errorf("unexpected request %s %v %q", r.URL, r.Header, body)
+				}
+
+				w.Header().Add("Vary", "Accept")
+				w.Header().Add("Vary", "Origin")
+				w.WriteHeader(http.StatusTeapot)
+				_, _ = w.Write([]byte("short and stout"))
+			},
+		),
+	)
+
+	event := events.APIGatewayV2HTTPRequest{
+		RawPath:         "/api/humblebundle/md",
+		RawQueryString:  "url=https://example.com",
+		Headers:         map[string]string{"accept": "application/json"},
+		Body:            "cGF5bG9hZA==",
+		IsBase64Encoded: true,
+	}
+	event.RequestContext.HTTP.Method = http.MethodGet
+	event.RequestContext.HTTP.SourceIP = "1.2.3.4"
+
+	response, err := handler(context.Background(), event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != http.StatusTeapot ||
+		response.Body != "short and stout" ||
+		response.IsBase64Encoded ||
+		response.Headers["Vary"] != "Accept, Origin" {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
+
+func TestServeIPv6Source(t *testing.T) {
+	handler := serve(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.RemoteAddr != "[2001:db8::1]:0" {
+					t.Errorf("RemoteAddr = %q", r.RemoteAddr)
+				}
+			},
+		),
+	)
+
+	event := events.APIGatewayV2HTTPRequest{RawPath: "/healthz"}
+	event.RequestContext.HTTP.Method = http.MethodGet
+	event.RequestContext.HTTP.SourceIP = "2001:db8::1"
+
+	if _, err := handler(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,28 @@
+// Package scraper registers the scraper functions with the Functions
+// Framework so the module can be deployed to Google Cloud Functions, e.g.
+//
+//	gcloud functions deploy humblebundle --gen2 --runtime=go121 \
+//	  --trigger-http --entry-point=HumbleBundle
+package scraper
+
+import (
+	"github.com/GoogleCloudPlatform/functions-framework-go/functions"
+
+	"humblebundle-scraper/api/docs"
+	"humblebundle-scraper/api/health"
+	"humblebundle-scraper/api/humblebundle"
+	"humblebundle-scraper/api/usage"
+	"humblebundle-scraper/api/woksoflife"
+)
+
+func init() {
+	functions.HTTP("HumbleBundle", humblebundle.Handler)
+	functions.HTTP("HumbleBundleList", humblebundle.ListHandler)
+	functions.HTTP("HumbleBundleFeed", humblebundle.FeedHandler)
+	functions.HTTP("WoksOfLife", woksoflife.Handler)
+	functions.HTTP("OpenAPI", docs.OpenAPIHandler)
+	functions.HTTP("Docs", docs.UIHandler)
+	functions.HTTP("Healthz", health.HealthzHandler)
+	functions.HTTP("Readyz", health.ReadyzHandler)
+	functions.HTTP("Usage", usage.BrowserlessHandler)
+}
@@ -0,0 +1,248 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	apiKeyHeader       = "X-API-Key"
+	defaultQuotaWindow = 24 * time.Hour
+	browserlessUnit    = 30 * time.Second
+)
+
+// KeyStore resolves API keys to the number of requests they may make per
+// quota window. A quota of zero means unlimited.
+type KeyStore interface {
+	Quota(key string) (quota int, ok bool)
+}
+
+// MapKeyStore is a KeyStore backed by a fixed set of keys.
+type MapKeyStore map[string]int
+
+func (s MapKeyStore) Quota(key string) (int, bool) {
+	quota, ok := s[key]
+	return quota, ok
+}
+
+// KeyUsage is the consumption of an API key in its current quota window.
+type KeyUsage struct {
+	Requests         int
+	BrowserlessUnits int
+	WindowStart      time.Time
+}
+
+// APIKeyAuth authenticates requests with the X-API-Key header and enforces
+// per-key request quotas and, when Limiter is set, rate limits. Authenticated
+// requests scrape with Token, so clients never need the Browserless token
+// themselves.
+type APIKeyAuth struct {
+	Store   KeyStore
+	Window  time.Duration
+	Token   string
+	Limiter *RateLimiter
+
+	mu    sync.Mutex
+	usage map[string]*KeyUsage
+	now   func() time.Time
+}
+
+// Usage returns a snapshot of the consumption of key.
+func (a *APIKeyAuth) Usage(key string) KeyUsage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if usage, ok := a.usage[key]; ok {
+		return *usage
+	}
+
+	return KeyUsage{}
+}
+
+func (a *APIKeyAuth) clock() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+
+	return time.Now()
+}
+
+// consume counts a request against key, returning a snapshot of its usage
+// and whether the request is within quota.
+func (a *APIKeyAuth) consume(key string, quota int) (KeyUsage, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.usage == nil {
+		a.usage = map[string]*KeyUsage{}
+	}
+
+	now := a.clock()
+	usage, ok := a.usage[key]
+	if !ok || now.Sub(usage.WindowStart) >= a.Window {
+		usage = &KeyUsage{WindowStart: now}
+		a.usage[key] = usage
+	}
+
+	if quota > 0 && usage.Requests >= quota {
+		return *usage, false
+	}
+
+	usage.Requests++
+	return *usage, true
+}
+
+// addUnits charges units to the current window of key, returning a snapshot
+// of its usage.
+func (a *APIKeyAuth) addUnits(key string, units int) KeyUsage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	usage, ok := a.usage[key]
+	if !ok {
+		return KeyUsage{}
+	}
+
+	usage.BrowserlessUnits += units
+	return *usage
+}
+
+// Wrap rejects requests without a known API key with 401 and requests over
+// the rate limit or quota with 429, reporting the quota in X-RateLimit-*
+// headers.
+func (a *APIKeyAuth) Wrap(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(apiKeyHeader)
+
+		quota, ok := a.Store.Quota(key)
+		if key == "" || !ok {
+			WriteProblem(
+				w,
+				http.StatusUnauthorized,
+				"invalid_api_key",
+				"a valid 'X-API-Key' header is required",
+			)
+			return
+		}
+
+		// rate limited requests are not counted against the quota
+		if a.Limiter != nil {
+			if ok, wait := a.Limiter.Allow(key); !ok {
+				rejectRateLimited(w, wait)
+				return
+			}
+		}
+
+		usage, allowed := a.consume(key, quota)
+		reset := usage.WindowStart.Add(a.Window)
+
+		if quota > 0 {
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(quota))
+			w.Header().Set(
+				"X-RateLimit-Remaining",
+				strconv.Itoa(quota-usage.Requests),
+			)
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		}
+
+		if !allowed {
+			retryAfter := int(math.Ceil(reset.Sub(a.clock()).Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			WriteProblem(
+				w,
+				http.StatusTooManyRequests,
+				"quota_exceeded",
+				"API key quota exceeded",
+			)
+			return
+		}
+
+		meter := &browserlessMeter{}
+		ctx := context.WithValue(r.Context(), usageKey, meter)
+		ctx = context.WithValue(ctx, tokenKey, a.Token)
+
+		handler(w, r.WithContext(ctx))
+
+		usage = a.addUnits(key, meter.Units())
+		LoggerFrom(ctx).Debug(
+			"api key usage",
+			"key", keyID(key),
+			"requests", usage.Requests,
+			"browserless_units", usage.BrowserlessUnits,
+		)
+	}
+}
+
+// APIKeyFromQuery lets clients that cannot send headers, such as feed
+// readers, authenticate with the apiKey query param instead.
+func APIKeyFromQuery(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if key := r.URL.Query().Get("apiKey"); key != "" && r.Header.Get(apiKeyHeader) == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set(apiKeyHeader, key)
+		}
+
+		handler(w, r)
+	}
+}
+
+// browserlessMeter accumulates the Browserless units spent by a request.
+type browserlessMeter struct {
+	mu    sync.Mutex
+	units int
+}
+
+// Units returns the units spent so far.
+func (m *browserlessMeter) Units() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.units
+}
+
+// recordBrowserlessUsage charges the elapsed browser time to the request in
+// ctx, rounded up to whole Browserless units.
+func recordBrowserlessUsage(ctx context.Context, elapsed time.Duration) {
+	chargeBrowserlessUnits(ctx, browserlessUnits(elapsed))
+}
+
+// chargeBrowserlessUnits adds units to the meter of the request in ctx.
+func chargeBrowserlessUnits(ctx context.Context, units int) {
+	meter, ok := ctx.Value(usageKey).(*browserlessMeter)
+	if !ok {
+		return
+	}
+
+	meter.mu.Lock()
+	defer meter.mu.Unlock()
+
+	meter.units += units
+}
+
+// browserlessUnits converts browser time to Browserless units, rounding up.
+func browserlessUnits(elapsed time.Duration) int {
+	return int(math.Ceil(float64(elapsed) / float64(browserlessUnit)))
+}
+
+// keyID identifies a key in logs and errors without revealing it.
+func keyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
+}
+
+// BrowserlessToken returns the token to scrape r with: the server-side token
+// for requests authenticated with an API key, else the browserlessToken
+// query param.
+func BrowserlessToken(r *http.Request) string {
+	if token, ok := r.Context().Value(tokenKey).(string); ok {
+		return token
+	}
+
+	return r.URL.Query().Get("browserlessToken")
+}
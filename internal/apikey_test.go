@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyAuthQuota(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	auth := &APIKeyAuth{
+		Store:  MapKeyStore{"alice": 2},
+		Window: time.Hour,
+		Token:  "server-token",
+		now:    func() time.Time { return now },
+	}
+
+	handler := auth.Wrap(
+		func(w http.ResponseWriter, r *http.Request) {
+			if token := BrowserlessToken(r); token != "server-token" {
+				t.Errorf("BrowserlessToken = %q", token)
+			}
+			recordBrowserlessUsage(r.Context(), 45*time.Second)
+		},
+	)
+
+	request := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			req.Header.Set(apiKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	if rec := request(""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("missing key: status %d", rec.Code)
+	}
+	if rec := request("mallory"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key: status %d", rec.Code)
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := request("alice"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, rec.Code)
+		}
+	}
+
+	rec := request("alice")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("over quota: status %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q", got)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "3600" {
+		t.Errorf("Retry-After = %q", got)
+	}
+
+	if usage := auth.Usage("alice"); usage.Requests != 2 || usage.BrowserlessUnits != 4 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+
+	now = now.Add(time.Hour)
+	if rec := request("alice"); rec.Code != http.StatusOK {
+		t.Errorf("new window: status %d", rec.Code)
+	}
+}
+
+func TestAPIKeyAuthRateLimit(t *testing.T) {
+	auth := &APIKeyAuth{
+		Store:   MapKeyStore{"alice": 0},
+		Window:  time.Hour,
+		Limiter: &RateLimiter{Rate: 0.1, Burst: 1},
+	}
+	handler := auth.Wrap(func(w http.ResponseWriter, r *http.Request) {})
+
+	request := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(apiKeyHeader, key)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := request("mallory" + strconv.Itoa(i)); code != http.StatusUnauthorized {
+			t.Errorf("unknown key: status %d", code)
+		}
+	}
+	if len(auth.Limiter.buckets) != 0 {
+		t.Error("unknown keys should not be given buckets")
+	}
+
+	if code := request("alice"); code != http.StatusOK {
+		t.Fatalf("first request: status %d", code)
+	}
+	if code := request("alice"); code != http.StatusTooManyRequests {
+		t.Errorf("second request: status %d", code)
+	}
+	if usage := auth.Usage("alice"); usage.Requests != 1 {
+		t.Errorf("rate limited requests should not count against the quota, got %+v", usage)
+	}
+}
+
+func TestAPIKeyAuthConcurrentRequests(t *testing.T) {
+	auth := &APIKeyAuth{Store: MapKeyStore{"alice": 0}, Window: time.Hour}
+	handler := auth.Wrap(
+		func(w http.ResponseWriter, r *http.Request) {
+			recordBrowserlessUsage(r.Context(), time.Second)
+		},
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(apiKeyHeader, "alice")
+			handler(httptest.NewRecorder(), req)
+		}()
+	}
+	wg.Wait()
+
+	if usage := auth.Usage("alice"); usage.Requests != 50 || usage.BrowserlessUnits != 50 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+}
+
+func TestAPIKeyFromQuery(t *testing.T) {
+	auth := &APIKeyAuth{Store: MapKeyStore{"alice": 0}, Window: time.Hour}
+	handler := APIKeyFromQuery(auth.Wrap(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/feed?apiKey=alice", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status %d, want the key in the query accepted", rec.Code)
+	}
+}
+
+func TestBrowserlessTokenFromQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?browserlessToken=abc", nil)
+	if token := BrowserlessToken(req); token != "abc" {
+		t.Errorf("BrowserlessToken = %q", token)
+	}
+
+	recordBrowserlessUsage(context.Background(), time.Minute)
+}
@@ -0,0 +1,351 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+
+	"humblebundle-scraper/internal/config"
+)
+
+// App holds the components built from the configuration, shared by every
+// handler of a process.
+type App struct {
+	Config    config.Config
+	Auth      *APIKeyAuth
+	IPLimiter *RateLimiter
+	CORS      CORSPolicy
+	Cache     *ResultCache
+	Proxies   *ProxyPool
+	Profiles  *ProfileRotation
+	Breaker   *CircuitBreaker
+	Budget    *BrowserlessBudget
+	Robots    *RobotsPolicy
+
+	// err is the configuration error of an App that cannot serve requests.
+	err error
+}
+
+// NewApp builds the components configured by cfg and makes its logger the
+// default one.
+func NewApp(cfg config.Config) (*App, error) {
+	slog.SetDefault(newLogger(cfg.Log))
+
+	app := &App{
+		Config: cfg,
+		CORS: CORSPolicy{
+			AllowedOrigins: cfg.CORS.AllowedOrigins,
+			AllowedHeaders: cfg.CORS.AllowedHeaders,
+		},
+		Profiles: &ProfileRotation{
+			Profiles:       cfg.Browser.Profiles,
+			AcceptLanguage: cfg.Browser.AcceptLanguage,
+		},
+		Budget: &BrowserlessBudget{MonthlyUnits: cfg.Budget.MonthlyUnits},
+	}
+
+	if len(cfg.Auth.APIKeys) > 0 {
+		app.Auth = &APIKeyAuth{
+			Store:  MapKeyStore(cfg.Auth.APIKeys),
+			Window: cfg.Auth.QuotaWindow,
+			Token:  cfg.BrowserlessToken,
+		}
+	}
+
+	switch cfg.Provider.Name {
+	case ProviderBrowserless:
+	case ProviderScrapingBee, ProviderScraperAPI, ProviderZyte:
+		if cfg.Provider.APIKey == "" {
+			return nil, fmt.Errorf("the %s provider requires an API key", cfg.Provider.Name)
+		}
+	default:
+		return nil, fmt.Errorf("unknown provider %q", cfg.Provider.Name)
+	}
+
+	for _, event := range cfg.Notify.Events {
+		if !slices.Contains(Events, Event(event)) {
+			return nil, fmt.Errorf("unknown notification event %q", event)
+		}
+	}
+
+	var err error
+	if cfg.RateLimit.IP != "" {
+		if app.IPLimiter, err = ParseRate(cfg.RateLimit.IP); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.RateLimit.Key != "" {
+		if app.Auth == nil {
+			return nil, errors.New("RATE_LIMIT_KEY requires API_KEYS")
+		}
+		if app.Auth.Limiter, err = ParseRate(cfg.RateLimit.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.RespectRobots {
+		app.Robots = &RobotsPolicy{}
+	}
+
+	if cfg.Breaker.Threshold > 0 {
+		app.Breaker = &CircuitBreaker{
+			Threshold: cfg.Breaker.Threshold,
+			Cooldown:  cfg.Breaker.Cooldown,
+		}
+	}
+
+	if len(cfg.Proxy.URLs) > 0 {
+		app.Proxies, err = NewProxyPool(
+			cfg.Proxy.URLs,
+			cfg.Proxy.FailureLimit,
+			cfg.Proxy.Cooldown,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var redisCache *RedisCache
+	if cfg.Cache.RedisURL != "" {
+		if redisCache, err = NewRedisCache(cfg.Cache.RedisURL, redisKeyPrefix); err != nil {
+			return nil, err
+		}
+
+		app.Budget.Redis = redisCache.Client
+	}
+
+	if cfg.Cache.TTL > 0 {
+		var store Cache = &MemoryCache{MaxEntries: cfg.Cache.MaxEntries}
+		if redisCache != nil {
+			store = redisCache
+		}
+
+		app.Cache = &ResultCache{
+			Store:    store,
+			TTL:      cfg.Cache.TTL,
+			StaleTTL: cfg.Cache.StaleTTL,
+		}
+	}
+
+	return app, nil
+}
+
+var (
+	defaultAppOnce sync.Once
+	defaultApp     *App
+)
+
+// DefaultApp returns the App of serverless functions, configured from the
+// CONFIG_FILE and the environment. An invalid configuration yields an App
+// answering every request with 500, rather than one silently running
+// without the intended authentication or limits.
+func DefaultApp() *App {
+	defaultAppOnce.Do(
+		func() {
+			cfg, err := config.Load(nil)
+			if err == nil {
+				defaultApp, err = NewApp(cfg)
+			}
+
+			if err != nil {
+				slog.Error("invalid configuration", "error", err)
+				defaultApp = &App{err: err}
+			}
+		},
+	)
+
+	return defaultApp
+}
+
+// servingErr returns why the App cannot serve HTTP requests: an invalid
+// configuration, or a provider other than Browserless without API keys.
+// Those providers scrape with the operator's key, which anonymous callers
+// would otherwise spend freely; the commands, run by the operator, may use
+// them without keys.
+func (a *App) servingErr() error {
+	if a.err != nil {
+		return a.err
+	}
+
+	if a.Config.Provider.Name != ProviderBrowserless && a.Auth == nil {
+		return fmt.Errorf("the %s provider requires API_KEYS", a.Config.Provider.Name)
+	}
+
+	return nil
+}
+
+// Handler wraps a scraper endpoint with the middlewares shared by every
+// function: tracing, request logging, CORS, rate limiting and API key
+// authentication, in that order.
+func (a *App) Handler(name string, handle http.HandlerFunc) http.HandlerFunc {
+	if a.servingErr() != nil {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			WriteProblem(
+				w,
+				http.StatusInternalServerError,
+				"misconfigured",
+				"the server configuration is invalid",
+			)
+		}
+	}
+
+	if a.Auth != nil {
+		handle = a.Auth.Wrap(handle)
+	}
+
+	return Traced(
+		name,
+		Logged(a.CORS.Wrap(RateLimit(a.IPLimiter, a.Config.RateLimit.TrustProxy, handle))),
+	)
+}
+
+// CacheControl returns the Cache-Control header of scraped responses,
+// cached by the CDN for sMaxAge seconds. Responses are private when API
+// keys are required, since the CDN would serve them without checking the
+// key.
+func (a *App) CacheControl(sMaxAge int) string {
+	if a.Auth != nil {
+		return "private, no-store"
+	}
+
+	return fmt.Sprintf("max-age=0, s-maxage=%d", sMaxAge)
+}
+
+// Notifiers returns the notification channels configured, each sent the
+// messages of the configured events only.
+func (a *App) Notifiers() Notifiers {
+	notify := a.Config.Notify
+
+	var notifiers Notifiers
+	if notify.WebhookURL != "" {
+		notifiers = append(notifiers, WebhookNotifier{URL: notify.WebhookURL})
+	}
+	if notify.SlackWebhookURL != "" {
+		notifiers = append(notifiers, SlackNotifier{WebhookURL: notify.SlackWebhookURL})
+	}
+	if notify.DiscordWebhookURL != "" {
+		notifiers = append(notifiers, DiscordNotifier{WebhookURL: notify.DiscordWebhookURL})
+	}
+	if notify.TelegramBotToken != "" && notify.TelegramChatID != "" {
+		notifiers = append(
+			notifiers,
+			TelegramNotifier{Token: notify.TelegramBotToken, ChatID: notify.TelegramChatID},
+		)
+	}
+	if notify.SMTP.Addr != "" && len(notify.SMTP.To) > 0 {
+		notifiers = append(
+			notifiers,
+			SMTPNotifier{
+				Addr:     notify.SMTP.Addr,
+				Username: notify.SMTP.Username,
+				Password: notify.SMTP.Password,
+				From:     notify.SMTP.From,
+				To:       notify.SMTP.To,
+			},
+		)
+	}
+
+	events := make([]Event, len(notify.Events))
+	for i, event := range notify.Events {
+		events[i] = Event(event)
+	}
+
+	for i, notifier := range notifiers {
+		notifiers[i] = EventFilter{Notifier: notifier, Events: events}
+	}
+
+	return notifiers
+}
+
+// CanFetch reports whether pages can be scraped with browserlessToken,
+// which only Browserless needs.
+func (a *App) CanFetch(browserlessToken string) bool {
+	return browserlessToken != "" || a.Config.Provider.Name != ProviderBrowserless
+}
+
+// Fetcher returns the fetcher of the configured provider. Browserless
+// scrapes with browserlessToken through the configured proxies and browser
+// profiles within its monthly budget. Every page is recorded when a record
+// directory is configured, robots.txt is honored when configured and sites
+// whose circuit is open fail fast.
+func (a *App) Fetcher(browserlessToken string) Fetcher {
+	provider := a.Config.Provider
+
+	var fetcher Fetcher
+	switch provider.Name {
+	case ProviderScrapingBee:
+		fetcher = ScrapingBeeFetcher{APIKey: provider.APIKey, Options: provider.Options}
+	case ProviderScraperAPI:
+		fetcher = ScraperAPIFetcher{APIKey: provider.APIKey, Options: provider.Options}
+	case ProviderZyte:
+		fetcher = ZyteFetcher{APIKey: provider.APIKey, Options: provider.Options}
+	default:
+		fetcher = BrowserlessFetcher{
+			Token:    browserlessToken,
+			Proxies:  a.Proxies,
+			Profiles: a.Profiles,
+			Budget:   a.Budget,
+		}
+	}
+
+	if a.Config.RecordDir != "" {
+		secrets := []string{browserlessToken}
+		if provider.APIKey != "" {
+			secrets = append(secrets, provider.APIKey)
+		}
+
+		fetcher = RecordingFetcher{
+			Fetcher: fetcher,
+			Dir:     a.Config.RecordDir,
+			Secrets: secrets,
+		}
+	}
+
+	if a.Robots != nil {
+		fetcher = RobotsFetcher{Fetcher: fetcher, Policy: a.Robots}
+	}
+
+	if a.Breaker != nil {
+		fetcher = BreakerFetcher{Fetcher: fetcher, Breaker: a.Breaker}
+	}
+
+	return fetcher
+}
+
+// ReadinessChecks returns the probes of the dependencies the scrapers need
+// to serve traffic: a valid configuration, Browserless when it renders the
+// pages and, when it is remote, the cache.
+func (a *App) ReadinessChecks() []Check {
+	if err := a.servingErr(); err != nil {
+		return []Check{
+			{
+				Name: "config",
+				Run:  func(context.Context) error { return err },
+			},
+		}
+	}
+
+	var checks []Check
+	if a.Config.Provider.Name == ProviderBrowserless {
+		checks = append(
+			checks, Check{
+				Name: "browserless",
+				Run: func(ctx context.Context) error {
+					return checkBrowserless(ctx, a.Config.BrowserlessToken)
+				},
+			},
+		)
+	}
+
+	if a.Cache != nil {
+		if store, ok := a.Cache.Store.(pinger); ok {
+			checks = append(checks, Check{Name: "cache", Run: store.Ping})
+		}
+	}
+
+	return checks
+}
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"humblebundle-scraper/internal/config"
+)
+
+func TestNewApp(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Auth.APIKeys = map[string]int{"alice": 1}
+	cfg.RateLimit.IP = "5/1s"
+	cfg.RecordDir = t.TempDir()
+
+	app, err := NewApp(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if app.Auth == nil || app.IPLimiter == nil || app.Auth.Limiter != nil || app.Cache == nil {
+		t.Errorf("unexpected app %+v", app)
+	}
+	fetcher, ok := app.Fetcher("token").(BreakerFetcher)
+	if !ok {
+		t.Fatal("expected a circuit breaking fetcher")
+	}
+	if _, ok := fetcher.Fetcher.(RecordingFetcher); !ok {
+		t.Error("expected a recording fetcher")
+	}
+
+	rec := httptest.NewRecorder()
+	app.Handler("test", func(w http.ResponseWriter, r *http.Request) {})(
+		rec,
+		httptest.NewRequest(http.MethodGet, "/", nil),
+	)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("requests without an API key should be rejected, got %d", rec.Code)
+	}
+
+	cfg.RateLimit.Key = "often"
+	if _, err := NewApp(cfg); err == nil {
+		t.Error("expected an error for an invalid rate limit")
+	}
+
+	cfg.RateLimit.Key = "5/1s"
+	cfg.Auth.APIKeys = nil
+	if _, err := NewApp(cfg); err == nil {
+		t.Error("expected an error for a key rate limit without API keys")
+	}
+}
+
+func TestNewAppProvider(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Breaker.Threshold = 0
+	cfg.Provider = config.Provider{Name: ProviderZyte, APIKey: "key"}
+	cfg.Auth.APIKeys = map[string]int{"alice": 0}
+
+	app, err := NewApp(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := app.Fetcher("").(ZyteFetcher); !ok || !app.CanFetch("") {
+		t.Error("expected a Zyte fetcher not needing a Browserless token")
+	}
+	if len(app.ReadinessChecks()) != 0 {
+		t.Error("Browserless should not be checked when it is not used")
+	}
+
+	// the commands may scrape without API keys, the endpoints may not
+	cfg.Auth.APIKeys = nil
+	app, err = NewApp(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	app.Handler("test", func(w http.ResponseWriter, r *http.Request) {})(
+		rec,
+		httptest.NewRequest(http.MethodGet, "/", nil),
+	)
+	if rec.Code != http.StatusInternalServerError || len(app.ReadinessChecks()) != 1 {
+		t.Errorf("a provider open to anonymous callers should not serve, got %d", rec.Code)
+	}
+
+	cfg.Auth.APIKeys = map[string]int{"alice": 0}
+	cfg.Provider.APIKey = ""
+	if _, err := NewApp(cfg); err == nil {
+		t.Error("expected an error for a provider without an API key")
+	}
+
+	cfg.Provider.Name = "selenium"
+	if _, err := NewApp(cfg); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestNewAppNotifiers(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Notify.SlackWebhookURL = "https://hooks.slack.example/services/x"
+	cfg.Notify.Events = []string{"new_bundle"}
+
+	app, err := NewApp(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Notifiers{
+		EventFilter{
+			Notifier: SlackNotifier{WebhookURL: cfg.Notify.SlackWebhookURL},
+			Events:   []Event{EventNewBundle},
+		},
+	}
+	if notifiers := app.Notifiers(); !reflect.DeepEqual(notifiers, want) {
+		t.Errorf("got notifiers %+v, want %+v", notifiers, want)
+	}
+
+	cfg.Notify.Events = []string{"bundle_expired"}
+	if _, err := NewApp(cfg); err == nil {
+		t.Error("expected an error for an unknown event")
+	}
+}
+
+func TestMisconfiguredApp(t *testing.T) {
+	app := &App{err: http.ErrNotSupported}
+
+	rec := httptest.NewRecorder()
+	app.Handler("test", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("misconfigured apps should not serve requests")
+	})(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status %d", rec.Code)
+	}
+	if checks := app.ReadinessChecks(); len(checks) != 1 || checks[0].Name != "config" {
+		t.Errorf("unexpected checks %+v", checks)
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	app := &App{}
+	if got := app.CacheControl(3600); got != "max-age=0, s-maxage=3600" {
+		t.Errorf("public responses: got %q", got)
+	}
+
+	app.Auth = &APIKeyAuth{}
+	if got := app.CacheControl(3600); got != "private, no-store" {
+		t.Errorf("authenticated responses: got %q", got)
+	}
+}
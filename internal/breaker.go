@@ -0,0 +1,196 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half-open"
+)
+
+type circuit struct {
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+// CircuitBreaker pauses the scraping of a source after Threshold
+// consecutive failures or bot detections. Once Cooldown has passed a
+// single probe request is let through: its success closes the circuit, its
+// failure opens it again.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	now      func() time.Time
+}
+
+func (b *CircuitBreaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+
+	return time.Now()
+}
+
+func (b *CircuitBreaker) circuit(source string) *circuit {
+	if b.circuits == nil {
+		b.circuits = map[string]*circuit{}
+	}
+
+	c, ok := b.circuits[source]
+	if !ok {
+		c = &circuit{state: circuitClosed}
+		b.circuits[source] = c
+	}
+
+	return c
+}
+
+// Allow reports whether a request to source may proceed.
+func (b *CircuitBreaker) Allow(source string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(source)
+
+	switch c.state {
+	case circuitOpen:
+		if b.clock().Sub(c.openedAt) < b.Cooldown {
+			return false
+		}
+
+		c.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// The probe request is still running.
+		return false
+	}
+
+	return true
+}
+
+// Report records the outcome of a request to source.
+func (b *CircuitBreaker) Report(source string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(source)
+
+	if !isSourceFailure(err) {
+		if c.state == circuitHalfOpen && err != nil {
+			// The probe did not tell anything about the source, let the
+			// next request probe it.
+			c.state = circuitOpen
+			return
+		}
+
+		if c.state != circuitClosed {
+			slog.Info("circuit closed", "source", source)
+		}
+
+		c.state = circuitClosed
+		c.failures = 0
+		return
+	}
+
+	c.failures++
+	if c.state == circuitHalfOpen || c.failures >= b.Threshold {
+		if c.state == circuitClosed {
+			slog.Warn("circuit opened", "source", source, "failures", c.failures)
+		}
+
+		c.state = circuitOpen
+		c.openedAt = b.clock()
+		c.failures = 0
+	}
+}
+
+// States returns the state of the circuit of every source seen.
+func (b *CircuitBreaker) States() map[string]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make(map[string]string, len(b.circuits))
+	for source, c := range b.circuits {
+		states[source] = c.state
+	}
+
+	return states
+}
+
+func (b *CircuitBreaker) state(source string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.circuit(source).state
+}
+
+// isSourceFailure reports whether err counts against the circuit of a
+// source: a failure of the site, or a bot detection, which further
+// requests would only prolong.
+func isSourceFailure(err error) bool {
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		switch upstreamErr.StatusCode {
+		case http.StatusForbidden, http.StatusTooManyRequests:
+			return true
+		}
+	}
+
+	return isUpstreamFailure(err)
+}
+
+// otherSources is the circuit shared by the sites the scraper does not
+// know, so callers cannot grow the circuits with arbitrary hosts.
+const otherSources = "other"
+
+// BreakerFetcher fails fast with ErrCircuitOpen while the circuit of the
+// site of the fetched URL is open.
+type BreakerFetcher struct {
+	Fetcher
+	Breaker *CircuitBreaker
+}
+
+func (f BreakerFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	source := sourceOf(url)
+	if source == "" {
+		source = otherSources
+	}
+
+	if !f.Breaker.Allow(source) {
+		f.trace(ctx, source)
+		return nil, fmt.Errorf("%s: %w", source, ErrCircuitOpen)
+	}
+
+	content, err := f.Fetcher.Fetch(ctx, url)
+	f.Breaker.Report(source, err)
+	f.trace(ctx, source)
+
+	return content, err
+}
+
+// trace records the state of the circuit of source after a fetch on the
+// span of ctx, from which tracing backends can chart the circuits.
+func (f BreakerFetcher) trace(ctx context.Context, source string) {
+	trace.SpanFromContext(ctx).AddEvent(
+		"circuit",
+		trace.WithAttributes(
+			attribute.String("circuit.source", source),
+			attribute.String("circuit.state", f.Breaker.state(source)),
+		),
+	)
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker := &CircuitBreaker{Threshold: 2, Cooldown: time.Minute}
+
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	failure := &UpstreamError{StatusCode: http.StatusBadGateway}
+
+	breaker.Report("a", failure)
+	if !breaker.Allow("a") {
+		t.Fatal("the circuit opened before the threshold")
+	}
+
+	breaker.Report("a", failure)
+	if breaker.Allow("a") {
+		t.Fatal("the circuit should be open")
+	}
+	if !breaker.Allow("b") {
+		t.Error("circuits should be per source")
+	}
+
+	now = now.Add(time.Minute)
+	if !breaker.Allow("a") {
+		t.Fatal("a probe should be allowed after the cooldown")
+	}
+	if breaker.Allow("a") {
+		t.Error("only one probe should be allowed")
+	}
+	if got := breaker.circuits["a"].state; got != "half-open" {
+		t.Errorf("state = %s, want half-open", got)
+	}
+
+	breaker.Report("a", failure)
+	if breaker.Allow("a") {
+		t.Fatal("a failed probe should open the circuit again")
+	}
+
+	now = now.Add(time.Minute)
+	breaker.Allow("a")
+	breaker.Report("a", nil)
+	if !breaker.Allow("a") || breaker.circuits["a"].state != "closed" {
+		t.Error("a successful probe should close the circuit")
+	}
+}
+
+func TestCircuitBreakerIgnoresAccountErrors(t *testing.T) {
+	breaker := &CircuitBreaker{Threshold: 1, Cooldown: time.Minute}
+
+	breaker.Report("a", &UpstreamError{StatusCode: http.StatusUnauthorized})
+	breaker.Report("a", context.Canceled)
+
+	if !breaker.Allow("a") {
+		t.Error("account and caller errors should not open the circuit")
+	}
+}
+
+func TestCircuitBreakerCountsBotDetections(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusTooManyRequests} {
+		breaker := &CircuitBreaker{Threshold: 2, Cooldown: time.Minute}
+
+		breaker.Report("a", &UpstreamError{StatusCode: status})
+		breaker.Report("a", &UpstreamError{StatusCode: status})
+
+		if breaker.Allow("a") {
+			t.Errorf("consecutive %d responses should open the circuit", status)
+		}
+	}
+}
+
+func TestBreakerFetcher(t *testing.T) {
+	fetcher := BreakerFetcher{
+		Fetcher: stubFetcher{},
+		Breaker: &CircuitBreaker{Threshold: 1, Cooldown: time.Minute},
+	}
+	fetcher.Breaker.Report("humblebundle", errors.New("timeout"))
+
+	_, err := fetcher.Fetch(context.Background(), "https://www.humblebundle.com/books/x")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+
+	if status, code := classifyError(err); status != http.StatusServiceUnavailable || code != "source_unavailable" {
+		t.Errorf("classified as %d %s", status, code)
+	}
+
+	// unknown hosts share a single circuit
+	_, _ = fetcher.Fetch(context.Background(), "https://a.example/")
+	_, err = fetcher.Fetch(context.Background(), "https://b.example/")
+	if !errors.Is(err, ErrCircuitOpen) || len(fetcher.Breaker.circuits) != 2 {
+		t.Errorf("err = %v with circuits %v, want one circuit for other sites", err, fetcher.Breaker.circuits)
+	}
+}
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// budgetKeyPrefix namespaces the accounts in Redis apart from the cached
+// results, so the cache commands neither list nor purge them.
+const budgetKeyPrefix = "humblebundle-scraper-budget:"
+
+// budgetRetention is how long the accounts of a month are kept in Redis,
+// long enough to report the previous month for a while.
+const budgetRetention = 62 * 24 * time.Hour
+
+// BrowserlessBudget accounts the Browserless units spent with each token in
+// the current calendar month (UTC).
+type BrowserlessBudget struct {
+	// MonthlyUnits is the budget of each token; 0 is unlimited.
+	MonthlyUnits int
+	// Redis, when set, keeps the accounts shared by every instance.
+	// Without it they live in the memory of the process, which on
+	// serverless platforms only counts what each instance spent since its
+	// cold start.
+	Redis *redis.Client
+
+	mu    sync.Mutex
+	month string
+	units map[string]int
+	now   func() time.Time
+}
+
+// BudgetReport is the Browserless usage of the current month, by token ID.
+type BudgetReport struct {
+	Month        string         `json:"month"`
+	MonthlyUnits int            `json:"monthlyUnits"`
+	Tokens       map[string]int `json:"tokens"`
+}
+
+func (b *BrowserlessBudget) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+
+	return time.Now()
+}
+
+func (b *BrowserlessBudget) currentMonth() string {
+	return b.clock().UTC().Format("2006-01")
+}
+
+func (b *BrowserlessBudget) redisKey(month string) string {
+	return budgetKeyPrefix + month
+}
+
+// rollover resets the accounts when a new month starts.
+func (b *BrowserlessBudget) rollover() {
+	month := b.currentMonth()
+	if b.units == nil || b.month != month {
+		b.month = month
+		b.units = map[string]int{}
+	}
+}
+
+// Charge adds units to the account of token.
+func (b *BrowserlessBudget) Charge(ctx context.Context, token string, units int) {
+	if b.Redis != nil {
+		// the units were spent even when the caller went away
+		ctx := context.WithoutCancel(ctx)
+		key := b.redisKey(b.currentMonth())
+
+		pipe := b.Redis.TxPipeline()
+		pipe.HIncrBy(ctx, key, keyID(token), int64(units))
+		pipe.Expire(ctx, key, budgetRetention)
+		if _, err := pipe.Exec(ctx); err != nil {
+			LoggerFrom(ctx).Warn("charging the browserless budget failed", "error", err)
+		}
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollover()
+	b.units[keyID(token)] += units
+}
+
+// Exceeded reports whether token has spent its monthly budget. Budgets
+// that cannot be read from Redis are not considered spent.
+func (b *BrowserlessBudget) Exceeded(ctx context.Context, token string) bool {
+	if b.MonthlyUnits <= 0 {
+		return false
+	}
+
+	if b.Redis != nil {
+		spent, err := b.Redis.HGet(ctx, b.redisKey(b.currentMonth()), keyID(token)).Int()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			LoggerFrom(ctx).Warn("reading the browserless budget failed", "error", err)
+		}
+
+		return spent >= b.MonthlyUnits
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollover()
+	return b.units[keyID(token)] >= b.MonthlyUnits
+}
+
+// Report returns the units spent by every token this month.
+func (b *BrowserlessBudget) Report(ctx context.Context) (BudgetReport, error) {
+	report := BudgetReport{MonthlyUnits: b.MonthlyUnits, Tokens: map[string]int{}}
+
+	if b.Redis != nil {
+		report.Month = b.currentMonth()
+
+		accounts, err := b.Redis.HGetAll(ctx, b.redisKey(report.Month)).Result()
+		if err != nil {
+			return BudgetReport{}, err
+		}
+
+		for token, spent := range accounts {
+			if report.Tokens[token], err = strconv.Atoi(spent); err != nil {
+				return BudgetReport{}, err
+			}
+		}
+
+		return report, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollover()
+
+	report.Month = b.month
+	for token, units := range b.units {
+		report.Tokens[token] = units
+	}
+
+	return report, nil
+}
+
+// ServeHTTP responds with the report of the budget.
+func (b *BrowserlessBudget) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report, err := b.Report(r.Context())
+	if err != nil {
+		LoggerFrom(r.Context()).Error("reading the browserless budget failed", "error", err)
+		WriteError(w, err)
+		return
+	}
+
+	body, _ := json.Marshal(report)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
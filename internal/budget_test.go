@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestBrowserlessBudget(t *testing.T) {
+	testBrowserlessBudget(t, &BrowserlessBudget{MonthlyUnits: 3})
+}
+
+func TestBrowserlessBudgetInRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	testBrowserlessBudget(t, &BrowserlessBudget{MonthlyUnits: 3, Redis: client})
+
+	// every instance sharing the server sees the units spent
+	other := &BrowserlessBudget{MonthlyUnits: 3, Redis: client}
+	other.now = func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }
+	other.Charge(context.Background(), "token", 3)
+
+	shared := &BrowserlessBudget{MonthlyUnits: 3, Redis: client}
+	shared.now = other.now
+	if !shared.Exceeded(context.Background(), "token") {
+		t.Error("the budget spent by another instance should be shared")
+	}
+	if ttl := server.TTL(budgetKeyPrefix + "2024-06"); ttl != budgetRetention {
+		t.Errorf("TTL = %s, want %s", ttl, budgetRetention)
+	}
+}
+
+func testBrowserlessBudget(t *testing.T, budget *BrowserlessBudget) {
+	ctx := context.Background()
+
+	now := time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC)
+	budget.now = func() time.Time { return now }
+
+	budget.Charge(ctx, "token", 2)
+	if budget.Exceeded(ctx, "token") {
+		t.Error("the budget is not spent yet")
+	}
+
+	budget.Charge(ctx, "token", 1)
+	if !budget.Exceeded(ctx, "token") {
+		t.Error("the budget should be spent")
+	}
+	if budget.Exceeded(ctx, "other") {
+		t.Error("budgets should be per token")
+	}
+
+	report, _ := budget.Report(ctx)
+	if report.Month != "2024-05" || report.Tokens[keyID("token")] != 3 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	now = now.Add(time.Hour)
+	if budget.Exceeded(ctx, "token") {
+		t.Error("the budget should reset with the month")
+	}
+}
+
+func TestBrowserlessBudgetUnlimited(t *testing.T) {
+	ctx := context.Background()
+	budget := &BrowserlessBudget{}
+	budget.Charge(ctx, "token", 1_000)
+
+	if budget.Exceeded(ctx, "token") {
+		t.Error("a zero budget is unlimited")
+	}
+}
+
+func TestBrowserlessBudgetHandler(t *testing.T) {
+	ctx := context.Background()
+	budget := &BrowserlessBudget{MonthlyUnits: 10}
+	budget.Charge(ctx, "token", 4)
+
+	rec := httptest.NewRecorder()
+	budget.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/usage", nil))
+
+	var report BudgetReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.MonthlyUnits != 10 || report.Tokens[keyID("token")] != 4 {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestStaticFetcherOverBudget(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept-Language") != "pt-BR" {
+					t.Errorf("unexpected headers %v", r.Header)
+				}
+
+				_, _ = w.Write([]byte("<p>static</p>"))
+			},
+		),
+	)
+	defer server.Close()
+
+	budget := &BrowserlessBudget{MonthlyUnits: 1}
+	budget.Charge(context.Background(), "token", 1)
+
+	fetcher := BrowserlessFetcher{
+		Token:    "token",
+		Profiles: &ProfileRotation{AcceptLanguage: "pt-BR"},
+		Budget:   budget,
+	}
+
+	routePublicClient(t, server)
+
+	content, err := fetcher.Fetch(context.Background(), "http://www.humblebundle.com/books/x")
+	if err != nil || string(content) != "<p>static</p>" {
+		t.Errorf("got %q, %v", content, err)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), server.URL); !errors.Is(err, ErrUnsupportedSite) {
+		t.Errorf("err = %v, want ErrUnsupportedSite", err)
+	}
+}
+
+func TestPublicClient(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(http.ResponseWriter, *http.Request) {},
+		),
+	)
+	defer server.Close()
+
+	if _, err := publicClient.Get(server.URL); !errors.Is(err, errPrivateAddress) {
+		t.Errorf("err = %v, want errPrivateAddress", err)
+	}
+
+	for address, public := range map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"::1":             false,
+		"fd00::1":         false,
+		"0.0.0.0":         false,
+	} {
+		if got := isPublicAddr(netip.MustParseAddr(address)); got != public {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", address, got, public)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const redisKeyPrefix = "humblebundle-scraper:"
+
+// CacheEntry is a serialized result and the time it was scraped.
+type CacheEntry struct {
+	Value    []byte
+	StoredAt time.Time
+}
+
+// Cache is a key-value store for scraped results. Entries may be dropped by
+// the store once ttl has elapsed.
+type Cache interface {
+	Get(ctx context.Context, key string) (CacheEntry, bool, error)
+	Set(ctx context.Context, key string, entry CacheEntry, ttl time.Duration) error
+}
+
+// CacheAdmin is implemented by stores whose entries can be listed and
+// purged from outside the process that cached them.
+type CacheAdmin interface {
+	Cache
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	Delete(ctx context.Context, keys ...string) (int, error)
+}
+
+// CacheStatus reports how a result was served, as sent in X-Cache.
+type CacheStatus string
+
+const (
+	CacheHit    CacheStatus = "HIT"
+	CacheStale  CacheStatus = "STALE"
+	CacheMiss   CacheStatus = "MISS"
+	CacheBypass CacheStatus = "BYPASS"
+)
+
+// ResultCache caches results in Store. Results younger than TTL are served
+// as is; results up to StaleTTL older than that are served while being
+// refreshed in the background.
+type ResultCache struct {
+	Store    Cache
+	TTL      time.Duration
+	StaleTTL time.Duration
+
+	mu           sync.Mutex
+	revalidating map[string]bool
+	now          func() time.Time
+}
+
+func (c *ResultCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+
+	return time.Now()
+}
+
+func (c *ResultCache) store(ctx context.Context, key string, value any) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	entry := CacheEntry{Value: encoded, StoredAt: c.clock()}
+	if err := c.Store.Set(ctx, key, entry, c.TTL+c.StaleTTL); err != nil {
+		LoggerFrom(ctx).Warn("caching result failed", "key", key, "error", err)
+	}
+}
+
+// startRevalidation marks key as being refreshed, returning false when a
+// refresh is already running.
+func (c *ResultCache) startRevalidation(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.revalidating == nil {
+		c.revalidating = map[string]bool{}
+	}
+	if c.revalidating[key] {
+		return false
+	}
+
+	c.revalidating[key] = true
+	return true
+}
+
+func (c *ResultCache) endRevalidation(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.revalidating, key)
+}
+
+// lookups deduplicates concurrent loads of the same key within the process.
+var lookups singleflight.Group
+
+// flight is the result of a shared load and the Browserless units it
+// spent.
+type flight[T any] struct {
+	result T
+	units  int
+}
+
+// deduplicated runs load once for all concurrent callers with the same key
+// and token, so a caller with an invalid token cannot fail the others. The
+// shared load is detached from the caller's cancellation so one client
+// disconnecting does not fail the others, and its Browserless units are
+// charged to every caller, as each would have spent them.
+func deduplicated[T any](
+	key string,
+	token string,
+	load func(context.Context) (T, error),
+) func(context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
+		shared, err, _ := lookups.Do(
+			key+"#"+keyID(token), func() (any, error) {
+				meter := &browserlessMeter{}
+				result, err := load(
+					context.WithValue(context.WithoutCancel(ctx), usageKey, meter),
+				)
+
+				return flight[T]{result: result, units: meter.Units()}, err
+			},
+		)
+
+		flight := shared.(flight[T])
+		chargeBrowserlessUnits(ctx, flight.units)
+
+		return flight.result, err
+	}
+}
+
+// Cached returns the result cached under key or loads and caches it.
+// Concurrent loads of the same key with the same token share a single call
+// to load. With refresh set the cache is bypassed and overwritten. A nil
+// cache always loads.
+func Cached[T any](
+	ctx context.Context,
+	cache *ResultCache,
+	key string,
+	token string,
+	refresh bool,
+	load func(context.Context) (T, error),
+) (T, CacheStatus, error) {
+	load = deduplicated(key, token, load)
+
+	if cache == nil {
+		result, err := load(ctx)
+		return result, CacheBypass, err
+	}
+
+	if !refresh {
+		entry, ok, err := cache.Store.Get(ctx, key)
+		if err != nil {
+			LoggerFrom(ctx).Warn("reading cache failed", "key", key, "error", err)
+		}
+
+		var result T
+		if ok && json.Unmarshal(entry.Value, &result) == nil {
+			age := cache.clock().Sub(entry.StoredAt)
+
+			if age < cache.TTL {
+				return result, CacheHit, nil
+			}
+
+			if age < cache.TTL+cache.StaleTTL {
+				if cache.startRevalidation(key) {
+					// serverless instances may be frozen after responding,
+					// which only delays the refresh to the next invocation
+					go func() {
+						defer cache.endRevalidation(key)
+
+						// the request's meter was read when it responded, so
+						// the refresh is a system spend, charged to no key
+						meter := &browserlessMeter{}
+						revalidateCtx := context.WithValue(
+							context.WithoutCancel(ctx),
+							usageKey,
+							meter,
+						)
+						if fresh, err := load(revalidateCtx); err == nil {
+							cache.store(revalidateCtx, key, fresh)
+						}
+
+						LoggerFrom(ctx).Info(
+							"cache refreshed in the background",
+							"key", key,
+							"browserless_units", meter.Units(),
+						)
+					}()
+				}
+
+				return result, CacheStale, nil
+			}
+		}
+	}
+
+	result, err := load(ctx)
+	if err != nil {
+		return result, CacheMiss, err
+	}
+
+	cache.store(ctx, key, result)
+
+	status := CacheMiss
+	if refresh {
+		status = CacheBypass
+	}
+
+	return result, status, nil
+}
+
+type memoryEntry struct {
+	entry     CacheEntry
+	expiresAt time.Time
+}
+
+// MemoryCache is an in-process Cache holding up to MaxEntries entries.
+type MemoryCache struct {
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+func (c *MemoryCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+
+	return time.Now()
+}
+
+func (c *MemoryCache) Get(_ context.Context, key string) (CacheEntry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stored, ok := c.entries[key]
+	if !ok {
+		return CacheEntry{}, false, nil
+	}
+
+	if c.clock().After(stored.expiresAt) {
+		delete(c.entries, key)
+		return CacheEntry{}, false, nil
+	}
+
+	return stored.entry, true, nil
+}
+
+func (c *MemoryCache) Set(
+	_ context.Context,
+	key string,
+	entry CacheEntry,
+	ttl time.Duration,
+) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock()
+
+	if c.entries == nil {
+		c.entries = map[string]memoryEntry{}
+	}
+
+	if _, exists := c.entries[key]; !exists && c.MaxEntries > 0 &&
+		len(c.entries) >= c.MaxEntries {
+		c.evict(now)
+	}
+
+	c.entries[key] = memoryEntry{entry: entry, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// evict drops the expired entries or, when none expired, the oldest one.
+func (c *MemoryCache) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+
+	evicted := false
+	for key, stored := range c.entries {
+		if now.After(stored.expiresAt) {
+			delete(c.entries, key)
+			evicted = true
+			continue
+		}
+
+		if oldestKey == "" || stored.entry.StoredAt.Before(oldest) {
+			oldestKey, oldest = key, stored.entry.StoredAt
+		}
+	}
+
+	if !evicted && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
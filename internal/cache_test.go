@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCached(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	clock := func() time.Time { return now }
+
+	cache := &ResultCache{
+		Store:    &MemoryCache{now: clock},
+		TTL:      time.Minute,
+		StaleTTL: time.Hour,
+		now:      clock,
+	}
+
+	var loads atomic.Int32
+	revalidated := make(chan struct{}, 1)
+	load := func(context.Context) (Bundle, error) {
+		n := loads.Add(1)
+		if n > 1 {
+			revalidated <- struct{}{}
+		}
+		return Bundle{Name: "bundle", Items: []string{string(rune('a' + n - 1))}}, nil
+	}
+
+	ctx := context.Background()
+	bundle, status, err := Cached(ctx, cache, "k", "token", false, load)
+	if err != nil || status != CacheMiss || bundle.Items[0] != "a" {
+		t.Fatalf("first lookup: %v %s %v", bundle, status, err)
+	}
+
+	bundle, status, _ = Cached(ctx, cache, "k", "token", false, load)
+	if status != CacheHit || bundle.Items[0] != "a" {
+		t.Fatalf("fresh lookup: %v %s", bundle, status)
+	}
+
+	now = now.Add(2 * time.Minute)
+	bundle, status, _ = Cached(ctx, cache, "k", "token", false, load)
+	if status != CacheStale || bundle.Items[0] != "a" {
+		t.Fatalf("stale lookup: %v %s", bundle, status)
+	}
+
+	select {
+	case <-revalidated:
+	case <-time.After(time.Second):
+		t.Fatal("stale entry was not revalidated")
+	}
+
+	// wait for the background refresh to release the key
+	for i := 0; i < 100 && !cache.startRevalidation("k"); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	cache.endRevalidation("k")
+
+	bundle, status, _ = Cached(ctx, cache, "k", "token", false, load)
+	if status != CacheHit || bundle.Items[0] != "b" {
+		t.Fatalf("revalidated lookup: %v %s", bundle, status)
+	}
+
+	bundle, status, _ = Cached(ctx, cache, "k", "token", true, load)
+	if status != CacheBypass || bundle.Items[0] != "c" {
+		t.Fatalf("refresh lookup: %v %s", bundle, status)
+	}
+	<-revalidated
+
+	now = now.Add(2 * time.Hour)
+	bundle, status, _ = Cached(ctx, cache, "k", "token", false, load)
+	if status != CacheMiss || bundle.Items[0] != "d" {
+		t.Fatalf("expired lookup: %v %s", bundle, status)
+	}
+	<-revalidated
+}
+
+func TestCachedRevalidationChargesNoKey(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	cache := &ResultCache{Store: &MemoryCache{}, TTL: time.Minute, StaleTTL: time.Hour}
+	cache.now = func() time.Time { return now }
+
+	revalidated := make(chan struct{})
+	load := func(ctx context.Context) (Bundle, error) {
+		recordBrowserlessUsage(ctx, time.Minute)
+		return Bundle{Name: "bundle"}, nil
+	}
+
+	_, _, _ = Cached(context.Background(), cache, "k", "token", false, load)
+
+	meter := &browserlessMeter{}
+	ctx := context.WithValue(context.Background(), usageKey, meter)
+	now = now.Add(2 * time.Minute)
+	_, status, _ := Cached(
+		ctx, cache, "k", "token", false, func(ctx context.Context) (Bundle, error) {
+			defer close(revalidated)
+			return load(ctx)
+		},
+	)
+	if status != CacheStale {
+		t.Fatalf("status %s, want a stale entry", status)
+	}
+
+	<-revalidated
+	if units := meter.Units(); units != 0 {
+		t.Errorf("the background refresh charged %d units to the request", units)
+	}
+}
+
+func TestCachedDoesNotStoreErrors(t *testing.T) {
+	cache := &ResultCache{Store: &MemoryCache{}, TTL: time.Minute}
+	failure := errors.New("boom")
+
+	_, _, err := Cached(
+		context.Background(), cache, "k", "token", false,
+		func(context.Context) (Bundle, error) { return Bundle{}, failure },
+	)
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the load error, got %v", err)
+	}
+
+	if _, ok, _ := cache.Store.Get(context.Background(), "k"); ok {
+		t.Error("failed loads should not be cached")
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	cache := &MemoryCache{MaxEntries: 2, now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	for i, key := range []string{"a", "b", "c"} {
+		entry := CacheEntry{StoredAt: now.Add(time.Duration(i) * time.Second)}
+		_ = cache.Set(ctx, key, entry, time.Hour)
+	}
+
+	if _, ok, _ := cache.Get(ctx, "a"); ok {
+		t.Error("the oldest entry should have been evicted")
+	}
+	if _, ok, _ := cache.Get(ctx, "c"); !ok {
+		t.Error("the newest entry should be cached")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok, _ := cache.Get(ctx, "c"); ok {
+		t.Error("expired entries should not be returned")
+	}
+}
+
+func TestCachedDeduplicatesConcurrentLoads(t *testing.T) {
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(context.Context) (Bundle, error) {
+		loads.Add(1)
+		<-release
+		return Bundle{Name: "bundle"}, nil
+	}
+
+	const callers = 10
+	results := make(chan Bundle, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			bundle, _, _ := Cached(context.Background(), nil, "dedup", "token", false, load)
+			results <- bundle
+		}()
+	}
+
+	// give every caller the chance to join the in-flight load
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < callers; i++ {
+		if bundle := <-results; bundle.Name != "bundle" {
+			t.Errorf("unexpected result %+v", bundle)
+		}
+	}
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("expected a single load, got %d", n)
+	}
+}
+
+func TestCachedDeduplicatesPerToken(t *testing.T) {
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (Bundle, error) {
+		loads.Add(1)
+		<-release
+		recordBrowserlessUsage(ctx, time.Minute)
+		return Bundle{Name: "bundle"}, nil
+	}
+
+	tokens := []string{"a", "a", "b"}
+	meters := make([]*browserlessMeter, len(tokens))
+	done := make(chan struct{}, len(tokens))
+	for i, token := range tokens {
+		meters[i] = &browserlessMeter{}
+		ctx := context.WithValue(context.Background(), usageKey, meters[i])
+		go func(token string) {
+			_, _, _ = Cached(ctx, nil, "per-token", token, false, load)
+			done <- struct{}{}
+		}(token)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for range tokens {
+		<-done
+	}
+
+	if n := loads.Load(); n != 2 {
+		t.Errorf("expected a load per token, got %d", n)
+	}
+	for i, meter := range meters {
+		if units := meter.Units(); units != 2 {
+			t.Errorf("caller %d was charged %d units, want 2", i, units)
+		}
+	}
+}
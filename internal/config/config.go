@@ -0,0 +1,678 @@
+// Package config loads the scraper configuration from, in increasing order
+// of precedence, built-in defaults, a YAML or TOML file, environment
+// variables and command line flags.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	// BrowserlessToken is used for requests authenticated with an API key.
+	BrowserlessToken string `yaml:"browserlessToken" toml:"browserlessToken"`
+	// RecordDir, when set, receives a copy of every fetched page.
+	RecordDir string `yaml:"recordDir" toml:"recordDir"`
+	// WaybackFallback scrapes the latest Wayback Machine snapshot of pages
+	// without the content looked for, such as expired bundles.
+	WaybackFallback bool `yaml:"waybackFallback" toml:"waybackFallback"`
+	// RespectRobots skips the pages robots.txt disallows and honors its
+	// crawl delays.
+	RespectRobots bool `yaml:"respectRobots" toml:"respectRobots"`
+
+	Log       Log       `yaml:"log" toml:"log"`
+	Auth      Auth      `yaml:"auth" toml:"auth"`
+	RateLimit RateLimit `yaml:"rateLimit" toml:"rateLimit"`
+	CORS      CORS      `yaml:"cors" toml:"cors"`
+	Cache     Cache     `yaml:"cache" toml:"cache"`
+	Proxy     Proxy     `yaml:"proxy" toml:"proxy"`
+	Browser   Browser   `yaml:"browser" toml:"browser"`
+	Breaker   Breaker   `yaml:"breaker" toml:"breaker"`
+	Budget    Budget    `yaml:"budget" toml:"budget"`
+	Provider  Provider  `yaml:"provider" toml:"provider"`
+	Monitor   Monitor   `yaml:"monitor" toml:"monitor"`
+	Notify    Notify    `yaml:"notify" toml:"notify"`
+}
+
+type Log struct {
+	// Level is debug, info, warn or error.
+	Level string `yaml:"level" toml:"level"`
+	// Format is text or json.
+	Format string `yaml:"format" toml:"format"`
+}
+
+type Auth struct {
+	// APIKeys maps each accepted key to its request quota, 0 for unlimited.
+	// Authentication is disabled when empty.
+	APIKeys     map[string]int `yaml:"apiKeys" toml:"apiKeys"`
+	QuotaWindow time.Duration  `yaml:"quotaWindow" toml:"quotaWindow"`
+}
+
+type RateLimit struct {
+	// IP and Key are "<requests>/<duration>" limits, e.g. "30/1m". Empty
+	// limits are not enforced.
+	IP  string `yaml:"ip" toml:"ip"`
+	Key string `yaml:"key" toml:"key"`
+	// TrustProxy identifies clients by the X-Real-Ip and X-Forwarded-For
+	// headers, which only a proxy overwriting them makes trustworthy. It
+	// defaults to true on Vercel.
+	TrustProxy bool `yaml:"trustProxy" toml:"trustProxy"`
+}
+
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowedOrigins" toml:"allowedOrigins"`
+	AllowedHeaders string   `yaml:"allowedHeaders" toml:"allowedHeaders"`
+}
+
+type Cache struct {
+	// TTL is how long results are fresh; 0 disables caching.
+	TTL time.Duration `yaml:"ttl" toml:"ttl"`
+	// StaleTTL is how long after TTL results are served while refreshed.
+	StaleTTL   time.Duration `yaml:"staleTTL" toml:"staleTTL"`
+	MaxEntries int           `yaml:"maxEntries" toml:"maxEntries"`
+	// RedisURL, when set, stores the cache and the Browserless budgets in
+	// Redis instead of memory.
+	RedisURL string `yaml:"redisURL" toml:"redisURL"`
+}
+
+type Proxy struct {
+	// URLs are http, https, socks4 or socks5 proxies Browserless loads the
+	// pages through, rotated on every fetch.
+	URLs []string `yaml:"urls" toml:"urls"`
+	// FailureLimit consecutive failures take a proxy out of the rotation
+	// for Cooldown.
+	FailureLimit int           `yaml:"failureLimit" toml:"failureLimit"`
+	Cooldown     time.Duration `yaml:"cooldown" toml:"cooldown"`
+}
+
+type Breaker struct {
+	// Threshold consecutive fetch failures of a site pause its scraping for
+	// Cooldown; 0 disables the circuit breaker.
+	Threshold int           `yaml:"threshold" toml:"threshold"`
+	Cooldown  time.Duration `yaml:"cooldown" toml:"cooldown"`
+}
+
+type Provider struct {
+	// Name is the service rendering the pages: browserless, scrapingbee,
+	// scraperapi or zyte. Proxies, browser profiles and the budget only
+	// apply to Browserless.
+	Name string `yaml:"name" toml:"name"`
+	// APIKey authenticates with providers other than Browserless, which
+	// use the browserlessToken of each request.
+	APIKey string `yaml:"apiKey" toml:"apiKey"`
+	// Options are passed as is to the provider API, e.g. country_code=us.
+	Options map[string]string `yaml:"options" toml:"options"`
+}
+
+type Monitor struct {
+	// Interval is how often the bundles on sale are polled.
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+	// Categories are the sections of the bundles index watched.
+	Categories []string `yaml:"categories" toml:"categories"`
+	// StateFile, when set, keeps the bundles already seen across restarts.
+	StateFile string `yaml:"stateFile" toml:"stateFile"`
+}
+
+// Notify configures the channels notifications are sent to. A channel is
+// enabled when its settings are present.
+type Notify struct {
+	// Events are the events notified of: new_bundle, scrape_failed and
+	// bot_detected.
+	Events            []string `yaml:"events" toml:"events"`
+	WebhookURL        string   `yaml:"webhookURL" toml:"webhookURL"`
+	SlackWebhookURL   string   `yaml:"slackWebhookURL" toml:"slackWebhookURL"`
+	DiscordWebhookURL string   `yaml:"discordWebhookURL" toml:"discordWebhookURL"`
+	TelegramBotToken  string   `yaml:"telegramBotToken" toml:"telegramBotToken"`
+	TelegramChatID    string   `yaml:"telegramChatID" toml:"telegramChatID"`
+	SMTP              SMTP     `yaml:"smtp" toml:"smtp"`
+}
+
+type SMTP struct {
+	// Addr is the host:port of the server.
+	Addr     string   `yaml:"addr" toml:"addr"`
+	Username string   `yaml:"username" toml:"username"`
+	Password string   `yaml:"password" toml:"password"`
+	From     string   `yaml:"from" toml:"from"`
+	To       []string `yaml:"to" toml:"to"`
+}
+
+type Budget struct {
+	// MonthlyUnits is how many Browserless units each token may spend per
+	// calendar month before pages are fetched without rendering; 0 is
+	// unlimited. The units are counted in Redis when Cache.RedisURL is set,
+	// else by each process on its own.
+	MonthlyUnits int `yaml:"monthlyUnits" toml:"monthlyUnits"`
+}
+
+type Browser struct {
+	// Profiles are rotated across fetches. Built-in desktop browser profiles
+	// are used when empty.
+	Profiles []BrowserProfile `yaml:"profiles" toml:"profiles"`
+	// AcceptLanguage is sent with every page request.
+	AcceptLanguage string `yaml:"acceptLanguage" toml:"acceptLanguage"`
+}
+
+// BrowserProfile is the identity a page is requested with: a user agent
+// and the headers, such as the sec-ch-ua client hints, matching it.
+type BrowserProfile struct {
+	UserAgent string            `yaml:"userAgent" toml:"userAgent"`
+	Headers   map[string]string `yaml:"headers" toml:"headers"`
+}
+
+func Defaults() Config {
+	return Config{
+		Log:  Log{Level: "info", Format: "text"},
+		Auth: Auth{QuotaWindow: 24 * time.Hour},
+		// Vercel sets VERCEL=1 in its functions
+		RateLimit: RateLimit{TrustProxy: os.Getenv("VERCEL") == "1"},
+		Cache: Cache{
+			TTL:        time.Hour,
+			StaleTTL:   24 * time.Hour,
+			MaxEntries: 1000,
+		},
+		Proxy:    Proxy{FailureLimit: 3, Cooldown: 5 * time.Minute},
+		Browser:  Browser{AcceptLanguage: "en-US,en;q=0.9"},
+		Breaker:  Breaker{Threshold: 5, Cooldown: time.Minute},
+		Provider: Provider{Name: "browserless"},
+		Monitor:  Monitor{Interval: time.Hour, Categories: []string{"books"}},
+		Notify: Notify{
+			Events: []string{"new_bundle", "scrape_failed", "bot_detected"},
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the file named by the
+// -config flag or the CONFIG_FILE variable, the environment and the flags
+// in args. Serverless functions, which have no command line, pass nil.
+func Load(args []string) (Config, error) {
+	cfg, _, err := LoadArgs(args)
+	return cfg, err
+}
+
+// LoadArgs is Load for commands, also returning the arguments left after
+// the flags.
+func LoadArgs(args []string) (Config, []string, error) {
+	cfg := Defaults()
+
+	flags := newFlagSet()
+	if err := flags.parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	path := os.Getenv("CONFIG_FILE")
+	if flags.configFile != "" {
+		path = flags.configFile
+	}
+
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	if err := loadEnv(&cfg); err != nil {
+		return Config{}, nil, err
+	}
+
+	if err := flags.apply(&cfg); err != nil {
+		return Config{}, nil, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return Config{}, nil, err
+	}
+
+	return cfg, flags.Args(), nil
+}
+
+// validate rejects the values the components cannot work with.
+func (cfg Config) validate() error {
+	if cfg.Auth.QuotaWindow <= 0 {
+		return fmt.Errorf("API_KEY_QUOTA_WINDOW: expected a positive duration, got %s", cfg.Auth.QuotaWindow)
+	}
+	if cfg.Monitor.Interval <= 0 {
+		return fmt.Errorf("MONITOR_INTERVAL: expected a positive duration, got %s", cfg.Monitor.Interval)
+	}
+
+	return nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, cfg)
+	case ".toml":
+		err = toml.Unmarshal(content, cfg)
+	default:
+		return fmt.Errorf("config file %s: expected a .yaml, .yml or .toml file", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ParseAPIKeys parses a comma separated list of "key" or "key:quota"
+// entries.
+func ParseAPIKeys(keys string) (map[string]int, error) {
+	parsed := map[string]int{}
+
+	for _, entry := range strings.Split(keys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, rawQuota, hasQuota := strings.Cut(entry, ":")
+
+		var quota int
+		if hasQuota {
+			var err error
+			quota, err = strconv.Atoi(rawQuota)
+			if err != nil || quota < 0 {
+				return nil, fmt.Errorf("invalid quota %q for an API key", rawQuota)
+			}
+		}
+
+		parsed[key] = quota
+	}
+
+	return parsed, nil
+}
+
+// ParseOptions parses a comma separated list of "name=value" entries.
+func ParseOptions(options string) (map[string]string, error) {
+	parsed := map[string]string{}
+
+	for _, entry := range splitList(options) {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid option %q: expected name=value", entry)
+		}
+
+		parsed[name] = value
+	}
+
+	return parsed, nil
+}
+
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// setting is a configuration value settable from the environment and the
+// command line.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	set   func(cfg *Config, value string) error
+}
+
+func stringSetting(target func(cfg *Config) *string) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		*target(cfg) = value
+		return nil
+	}
+}
+
+func boolSetting(target func(cfg *Config) *bool) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		*target(cfg) = parsed
+		return nil
+	}
+}
+
+func intSetting(target func(cfg *Config) *int) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+
+		*target(cfg) = parsed
+		return nil
+	}
+}
+
+func durationSetting(target func(cfg *Config) *time.Duration) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		*target(cfg) = duration
+		return nil
+	}
+}
+
+var settings = []setting{
+	{
+		"BROWSERLESS_TOKEN", "browserless-token",
+		"Browserless token used for requests authenticated with an API key",
+		stringSetting(func(cfg *Config) *string { return &cfg.BrowserlessToken }),
+	},
+	{
+		"SCRAPER_RECORD_DIR", "record-dir",
+		"directory every fetched page is recorded to",
+		stringSetting(func(cfg *Config) *string { return &cfg.RecordDir }),
+	},
+	{
+		"WAYBACK_FALLBACK", "wayback-fallback",
+		"scrape the latest Wayback Machine snapshot of expired pages",
+		boolSetting(func(cfg *Config) *bool { return &cfg.WaybackFallback }),
+	},
+	{
+		"SCRAPER_PROVIDER", "provider",
+		"service rendering the pages: browserless, scrapingbee, scraperapi or zyte; all but browserless require API_KEYS to be served",
+		stringSetting(func(cfg *Config) *string { return &cfg.Provider.Name }),
+	},
+	{
+		"SCRAPER_PROVIDER_API_KEY", "provider-api-key",
+		"API key of the scrapingbee, scraperapi or zyte provider",
+		stringSetting(func(cfg *Config) *string { return &cfg.Provider.APIKey }),
+	},
+	{
+		"SCRAPER_PROVIDER_OPTIONS", "provider-options",
+		"comma separated name=value options passed to the provider API",
+		func(cfg *Config, value string) error {
+			options, err := ParseOptions(value)
+			if err != nil {
+				return err
+			}
+
+			cfg.Provider.Options = options
+			return nil
+		},
+	},
+	{
+		"RESPECT_ROBOTS_TXT", "respect-robots-txt",
+		"skip the pages robots.txt disallows and honor its crawl delays",
+		boolSetting(func(cfg *Config) *bool { return &cfg.RespectRobots }),
+	},
+	{
+		"LOG_LEVEL", "log-level",
+		"minimum log level: debug, info, warn or error",
+		stringSetting(func(cfg *Config) *string { return &cfg.Log.Level }),
+	},
+	{
+		"LOG_FORMAT", "log-format",
+		"log format: text or json",
+		stringSetting(func(cfg *Config) *string { return &cfg.Log.Format }),
+	},
+	{
+		"API_KEYS", "api-keys",
+		"comma separated key or key:quota entries; enables API key authentication",
+		func(cfg *Config, value string) error {
+			keys, err := ParseAPIKeys(value)
+			if err != nil {
+				return err
+			}
+
+			cfg.Auth.APIKeys = keys
+			return nil
+		},
+	},
+	{
+		"API_KEY_QUOTA_WINDOW", "api-key-quota-window",
+		"period API key quotas apply to",
+		durationSetting(func(cfg *Config) *time.Duration { return &cfg.Auth.QuotaWindow }),
+	},
+	{
+		"RATE_LIMIT_IP", "rate-limit-ip",
+		"per client IP limit, e.g. 30/1m",
+		stringSetting(func(cfg *Config) *string { return &cfg.RateLimit.IP }),
+	},
+	{
+		"RATE_LIMIT_KEY", "rate-limit-key",
+		"per API key limit, e.g. 30/1m",
+		stringSetting(func(cfg *Config) *string { return &cfg.RateLimit.Key }),
+	},
+	{
+		"TRUST_PROXY", "trust-proxy",
+		"identify clients by the X-Real-Ip and X-Forwarded-For headers a proxy sets",
+		boolSetting(func(cfg *Config) *bool { return &cfg.RateLimit.TrustProxy }),
+	},
+	{
+		"CORS_ALLOWED_ORIGINS", "cors-allowed-origins",
+		"comma separated origins allowed to call the API, * for any",
+		func(cfg *Config, value string) error {
+			cfg.CORS.AllowedOrigins = splitList(value)
+			return nil
+		},
+	},
+	{
+		"CORS_ALLOWED_HEADERS", "cors-allowed-headers",
+		"request headers allowed in cross-origin calls",
+		stringSetting(func(cfg *Config) *string { return &cfg.CORS.AllowedHeaders }),
+	},
+	{
+		"CACHE_TTL", "cache-ttl",
+		"how long scraped results are fresh; 0 disables caching",
+		durationSetting(func(cfg *Config) *time.Duration { return &cfg.Cache.TTL }),
+	},
+	{
+		"CACHE_STALE_TTL", "cache-stale-ttl",
+		"how long stale results are served while refreshed",
+		durationSetting(func(cfg *Config) *time.Duration { return &cfg.Cache.StaleTTL }),
+	},
+	{
+		"CACHE_MAX_ENTRIES", "cache-max-entries",
+		"maximum number of results cached in memory",
+		intSetting(func(cfg *Config) *int { return &cfg.Cache.MaxEntries }),
+	},
+	{
+		"REDIS_URL", "redis-url",
+		"redis:// URL of a shared cache, also keeping the Browserless budgets",
+		stringSetting(func(cfg *Config) *string { return &cfg.Cache.RedisURL }),
+	},
+	{
+		"PROXY_URLS", "proxy-urls",
+		"comma separated proxies pages are loaded through, e.g. socks5://host:1080",
+		func(cfg *Config, value string) error {
+			cfg.Proxy.URLs = splitList(value)
+			return nil
+		},
+	},
+	{
+		"PROXY_FAILURE_LIMIT", "proxy-failure-limit",
+		"consecutive failures taking a proxy out of the rotation",
+		intSetting(func(cfg *Config) *int { return &cfg.Proxy.FailureLimit }),
+	},
+	{
+		"PROXY_COOLDOWN", "proxy-cooldown",
+		"how long a failing proxy is out of the rotation",
+		durationSetting(func(cfg *Config) *time.Duration { return &cfg.Proxy.Cooldown }),
+	},
+	{
+		"BREAKER_THRESHOLD", "breaker-threshold",
+		"consecutive fetch failures pausing the scraping of a site; 0 disables",
+		intSetting(func(cfg *Config) *int { return &cfg.Breaker.Threshold }),
+	},
+	{
+		"BREAKER_COOLDOWN", "breaker-cooldown",
+		"how long the scraping of a failing site is paused",
+		durationSetting(func(cfg *Config) *time.Duration { return &cfg.Breaker.Cooldown }),
+	},
+	{
+		"BROWSERLESS_MONTHLY_UNITS", "browserless-monthly-units",
+		"Browserless units each token may spend per month; 0 is unlimited",
+		intSetting(func(cfg *Config) *int { return &cfg.Budget.MonthlyUnits }),
+	},
+	{
+		"MONITOR_INTERVAL", "monitor-interval",
+		"how often the monitor polls the bundles on sale",
+		durationSetting(func(cfg *Config) *time.Duration { return &cfg.Monitor.Interval }),
+	},
+	{
+		"MONITOR_CATEGORIES", "monitor-categories",
+		"comma separated bundle categories the monitor watches: books, games or software",
+		func(cfg *Config, value string) error {
+			cfg.Monitor.Categories = splitList(value)
+			return nil
+		},
+	},
+	{
+		"MONITOR_STATE_FILE", "monitor-state-file",
+		"file keeping the bundles the monitor has seen across restarts",
+		stringSetting(func(cfg *Config) *string { return &cfg.Monitor.StateFile }),
+	},
+	{
+		"NOTIFY_EVENTS", "notify-events",
+		"comma separated events notified of: new_bundle, scrape_failed or bot_detected",
+		func(cfg *Config, value string) error {
+			cfg.Notify.Events = splitList(value)
+			return nil
+		},
+	},
+	{
+		"NOTIFY_WEBHOOK_URL", "notify-webhook-url",
+		"URL notifications are posted to as JSON",
+		stringSetting(func(cfg *Config) *string { return &cfg.Notify.WebhookURL }),
+	},
+	{
+		"SLACK_WEBHOOK_URL", "slack-webhook-url",
+		"Slack incoming webhook notifications are posted to",
+		stringSetting(func(cfg *Config) *string { return &cfg.Notify.SlackWebhookURL }),
+	},
+	{
+		"DISCORD_WEBHOOK_URL", "discord-webhook-url",
+		"Discord webhook notifications are posted to",
+		stringSetting(func(cfg *Config) *string { return &cfg.Notify.DiscordWebhookURL }),
+	},
+	{
+		"TELEGRAM_BOT_TOKEN", "telegram-bot-token",
+		"token of the Telegram bot sending notifications",
+		stringSetting(func(cfg *Config) *string { return &cfg.Notify.TelegramBotToken }),
+	},
+	{
+		"TELEGRAM_CHAT_ID", "telegram-chat-id",
+		"Telegram chat notifications are sent to",
+		stringSetting(func(cfg *Config) *string { return &cfg.Notify.TelegramChatID }),
+	},
+	{
+		"SMTP_ADDR", "smtp-addr",
+		"host:port of the SMTP server notifications are emailed through",
+		stringSetting(func(cfg *Config) *string { return &cfg.Notify.SMTP.Addr }),
+	},
+	{
+		"SMTP_USERNAME", "smtp-username",
+		"SMTP username",
+		stringSetting(func(cfg *Config) *string { return &cfg.Notify.SMTP.Username }),
+	},
+	{
+		"SMTP_PASSWORD", "smtp-password",
+		"SMTP password",
+		stringSetting(func(cfg *Config) *string { return &cfg.Notify.SMTP.Password }),
+	},
+	{
+		"SMTP_FROM", "smtp-from",
+		"sender of notification emails",
+		stringSetting(func(cfg *Config) *string { return &cfg.Notify.SMTP.From }),
+	},
+	{
+		"SMTP_TO", "smtp-to",
+		"comma separated recipients of notification emails",
+		func(cfg *Config, value string) error {
+			cfg.Notify.SMTP.To = splitList(value)
+			return nil
+		},
+	},
+	{
+		"ACCEPT_LANGUAGE", "accept-language",
+		"Accept-Language header pages are requested with",
+		stringSetting(func(cfg *Config) *string { return &cfg.Browser.AcceptLanguage }),
+	},
+}
+
+func loadEnv(cfg *Config) error {
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env)
+		if !ok || value == "" {
+			continue
+		}
+
+		if err := s.set(cfg, value); err != nil {
+			return fmt.Errorf("%s: %w", s.env, err)
+		}
+	}
+
+	return nil
+}
+
+type flagSet struct {
+	*flag.FlagSet
+	configFile string
+	values     map[string]*string
+}
+
+func newFlagSet() *flagSet {
+	flags := &flagSet{
+		FlagSet: flag.NewFlagSet("scraper", flag.ContinueOnError),
+		values:  map[string]*string{},
+	}
+
+	flags.StringVar(&flags.configFile, "config", "", "YAML or TOML configuration file")
+	for _, s := range settings {
+		flags.values[s.flag] = flags.String(s.flag, "", s.usage+" (env "+s.env+")")
+	}
+
+	return flags
+}
+
+func (f *flagSet) parse(args []string) error {
+	if args == nil {
+		return nil
+	}
+
+	return f.Parse(args)
+}
+
+// apply sets the values of the flags present on the command line.
+func (f *flagSet) apply(cfg *Config) error {
+	var err error
+
+	f.Visit(
+		func(visited *flag.Flag) {
+			for _, s := range settings {
+				if s.flag != visited.Name || err != nil {
+					continue
+				}
+
+				if setErr := s.set(cfg, *f.values[s.flag]); setErr != nil {
+					err = fmt.Errorf("-%s: %w", s.flag, setErr)
+				}
+			}
+		},
+	)
+
+	return err
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(cfg, Defaults()) {
+		t.Errorf("got %+v, want the defaults", cfg)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(
+		t, "config.yaml", `
+log:
+  level: debug
+  format: json
+cache:
+  ttl: 10m
+  redisURL: redis://file
+auth:
+  apiKeys:
+    alice: 5
+cors:
+  allowedOrigins: [https://file.example]
+browser:
+  profiles:
+    - userAgent: Mozilla/5.0 (X11; Linux x86_64) Firefox/126.0
+`,
+	)
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("CACHE_TTL", "20m")
+	t.Setenv("WAYBACK_FALLBACK", "true")
+	t.Setenv("PROXY_URLS", "http://a:8080, socks5://b:1080")
+
+	cfg, err := Load([]string{"-cache-ttl", "30m", "-rate-limit-ip", "10/1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Log.Format != "json" {
+		t.Errorf("file value not applied: %+v", cfg.Log)
+	}
+	if cfg.Log.Level != "warn" {
+		t.Errorf("env should override the file: %+v", cfg.Log)
+	}
+	if cfg.Cache.TTL != 30*time.Minute {
+		t.Errorf("flags should override the env: %v", cfg.Cache.TTL)
+	}
+	if cfg.Cache.StaleTTL != 24*time.Hour || cfg.Cache.RedisURL != "redis://file" {
+		t.Errorf("unexpected cache config %+v", cfg.Cache)
+	}
+	if cfg.Auth.APIKeys["alice"] != 5 || cfg.Auth.QuotaWindow != 24*time.Hour {
+		t.Errorf("unexpected auth config %+v", cfg.Auth)
+	}
+	if !reflect.DeepEqual(cfg.CORS.AllowedOrigins, []string{"https://file.example"}) {
+		t.Errorf("unexpected CORS config %+v", cfg.CORS)
+	}
+	if !reflect.DeepEqual(cfg.Proxy.URLs, []string{"http://a:8080", "socks5://b:1080"}) ||
+		cfg.Proxy.FailureLimit != 3 {
+		t.Errorf("unexpected proxy config %+v", cfg.Proxy)
+	}
+	if len(cfg.Browser.Profiles) != 1 || cfg.Browser.AcceptLanguage != "en-US,en;q=0.9" {
+		t.Errorf("unexpected browser config %+v", cfg.Browser)
+	}
+	if !cfg.WaybackFallback {
+		t.Error("boolean env value not applied")
+	}
+	if cfg.RateLimit.IP != "10/1m" {
+		t.Errorf("unexpected rate limit config %+v", cfg.RateLimit)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := writeFile(
+		t, "config.toml", `
+browserlessToken = "from-file"
+
+[cache]
+ttl = "5m"
+
+[auth.apiKeys]
+bob = 0
+`,
+	)
+
+	cfg, err := Load([]string{"-config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.BrowserlessToken != "from-file" || cfg.Cache.TTL != 5*time.Minute {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if quota, ok := cfg.Auth.APIKeys["bob"]; !ok || quota != 0 {
+		t.Errorf("unexpected API keys %v", cfg.Auth.APIKeys)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Setenv("CACHE_TTL", "soon")
+	if _, err := Load(nil); err == nil {
+		t.Error("expected an error for an invalid duration")
+	}
+
+	t.Setenv("CACHE_TTL", "")
+	if _, err := Load([]string{"-config", writeFile(t, "config.ini", "")}); err == nil {
+		t.Error("expected an error for an unsupported file type")
+	}
+
+	if _, err := Load([]string{"-api-key-quota-window", "0s"}); err == nil {
+		t.Error("expected an error for an empty quota window")
+	}
+	if _, err := Load([]string{"-monitor-interval", "-1m"}); err == nil {
+		t.Error("expected an error for a negative monitor interval")
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys("alice:2, bob,")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(keys, map[string]int{"alice": 2, "bob": 0}) {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	if _, err := ParseAPIKeys("alice:lots"); err == nil {
+		t.Error("expected an error for a non-numeric quota")
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	options, err := ParseOptions("country_code=us, premium_proxy=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(options, map[string]string{"country_code": "us", "premium_proxy": "true"}) {
+		t.Errorf("unexpected options %v", options)
+	}
+
+	if _, err := ParseOptions("render"); err == nil {
+		t.Error("expected an error for an option without a value")
+	}
+}
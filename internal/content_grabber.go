@@ -2,24 +2,179 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"regexp"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"humblebundle-scraper/internal/config"
 )
 
+// maxUpstreamErrorLength caps how much of an upstream error body is kept.
+const maxUpstreamErrorLength = 200
+
+const browserlessURL = "https://chrome.browserless.io"
+
+// Fetcher retrieves the HTML content of a page.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) ([]byte, error)
+}
+
+// BrowserlessFetcher renders pages through the Browserless content API.
+type BrowserlessFetcher struct {
+	Token string
+	// Proxies and Profiles, when set, are rotated across fetches.
+	Proxies  *ProxyPool
+	Profiles *ProfileRotation
+	// Budget, when set, accounts the units spent with Token. Pages are
+	// fetched without rendering once its monthly budget is spent.
+	Budget *BrowserlessBudget
+}
+
+// browserlessOptions customizes how Browserless loads a page.
+type browserlessOptions struct {
+	proxy   string
+	profile config.BrowserProfile
+}
+
+func (f BrowserlessFetcher) Fetch(ctx context.Context, url string) (
+	[]byte,
+	error,
+) {
+	if f.Budget != nil && f.Budget.Exceeded(ctx, f.Token) {
+		LoggerFrom(ctx).Warn(
+			"browserless budget spent, fetching without rendering",
+			"url", url,
+		)
+		return StaticFetcher{Profiles: f.Profiles}.Fetch(ctx, url)
+	}
+
+	ctx, span := tracer.Start(ctx, "browserless.content")
+	defer span.End()
+	span.SetAttributes(attribute.String("scraper.url", url))
+
+	var options browserlessOptions
+	if f.Proxies != nil {
+		options.proxy = f.Proxies.Next()
+		span.SetAttributes(attribute.String("scraper.proxy", proxyHost(options.proxy)))
+	}
+	if f.Profiles != nil {
+		options.profile = f.Profiles.Next()
+	}
+
+	start := time.Now()
+	content, err := browserlessRequest(ctx, f.Token, url, "content", "", options)
+	recordBrowserlessUsage(ctx, time.Since(start))
+	if f.Budget != nil {
+		f.Budget.Charge(ctx, f.Token, browserlessUnits(time.Since(start)))
+	}
+	if f.Proxies != nil {
+		f.Proxies.Report(options.proxy, err)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	LoggerFrom(ctx).Debug(
+		"page fetched",
+		"url", url,
+		"bytes", len(content),
+		"duration", time.Since(start),
+	)
+
+	return content, err
+}
+
+// maxStaticPageSize caps the pages downloaded by StaticFetcher.
+const maxStaticPageSize = 10 << 20
+
+// StaticFetcher downloads pages of the scraped sites without rendering
+// them, for when Browserless cannot be used. Content added by scripts is
+// missing.
+type StaticFetcher struct {
+	Profiles *ProfileRotation
+}
+
+func (f StaticFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "static.content")
+	defer span.End()
+	span.SetAttributes(attribute.String("scraper.url", url))
+
+	// the server requests the page itself, so it must not be pointed at
+	// internal services
+	if sourceOf(url) == "" {
+		return nil, ErrUnsupportedSite
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Profiles != nil {
+		profile := f.Profiles.Next()
+		req.Header.Set("User-Agent", profile.UserAgent)
+		for name, value := range profile.Headers {
+			req.Header.Set(name, value)
+		}
+	}
+
+	resp, err := publicClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxStaticPageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		err = &UpstreamError{
+			StatusCode: resp.StatusCode,
+			Body:       truncate(string(body), maxUpstreamErrorLength),
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return body, nil
+}
+
 func browserlessRequest(
+	ctx context.Context,
 	browserlessToken string,
 	url string,
 	endpoint string,
 	preprocessor string,
+	options browserlessOptions,
 ) ([]byte, error) {
-	payload := make(map[string]string)
+	payload := make(map[string]any)
 
 	switch endpoint {
 	case "content":
 		payload["url"] = url
+		if options.profile.UserAgent != "" {
+			payload["userAgent"] = options.profile.UserAgent
+		}
+		if len(options.profile.Headers) > 0 {
+			payload["setExtraHTTPHeaders"] = options.profile.Headers
+		}
 		break
 	case "function":
 		payload["context"] = fmt.Sprintf("{\"url\": %s}", url)
@@ -37,13 +192,20 @@ func browserlessRequest(
 		return nil, err
 	}
 
-	resp, err := http.Post(
-		fmt.Sprintf(
-			"https://chrome.browserless.io/%s?token=%s&headless=true&blockAds=true",
-			endpoint,
-			browserlessToken,
-		),
-		"application/json",
+	requestURL := fmt.Sprintf(
+		"%s/%s?token=%s&headless=true&blockAds=true",
+		browserlessURL,
+		endpoint,
+		browserlessToken,
+	)
+	if options.proxy != "" {
+		requestURL += "&--proxy-server=" + neturl.QueryEscape(options.proxy)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		requestURL,
 		bytes.NewBuffer(reqBody),
 	)
 
@@ -51,6 +213,14 @@ func browserlessRequest(
 		return nil, err
 	}
 
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return nil, withoutURL(err)
+	}
+
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
 	}(resp.Body)
@@ -61,19 +231,55 @@ func browserlessRequest(
 		return nil, err
 	}
 
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, &UpstreamError{
+			StatusCode: resp.StatusCode,
+			Body:       truncate(string(body), maxUpstreamErrorLength),
+		}
+	}
+
 	return body, nil
 }
 
-func GrabContent(browserlessToken string, url string) ([]byte, error) {
-	return browserlessRequest(browserlessToken, url, "content", "")
+func GrabContent(
+	ctx context.Context,
+	browserlessToken string,
+	url string,
+) ([]byte, error) {
+	return browserlessRequest(ctx, browserlessToken, url, "content", "", browserlessOptions{})
 }
 
 func GrabContentPreprocessing(
+	ctx context.Context,
 	browserlessToken string,
 	url string,
 	jsCode string,
 ) ([]byte, error) {
-	return browserlessRequest(browserlessToken, url, "function", jsCode)
+	return browserlessRequest(ctx, browserlessToken, url, "function", jsCode, browserlessOptions{})
+}
+
+// withoutURL unwraps the request URL from HTTP client errors, since the
+// Browserless URLs carry the token.
+func withoutURL(err error) error {
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+
+	return err
+}
+
+func truncate(s string, length int) string {
+	// cut on a rune boundary, so multi-byte characters stay whole
+	runes := 0
+	for i := range s {
+		if runes == length {
+			return s[:i] + "…"
+		}
+		runes++
+	}
+
+	return s
 }
 
 func minifyJavascript(jsCode string) string {
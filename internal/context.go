@@ -0,0 +1,9 @@
+package internal
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	usageKey
+	tokenKey
+)
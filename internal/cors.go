@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const defaultCORSHeaders = "Accept, Content-Type, X-API-Key, X-Request-Id"
+
+// exposedHeaders are the response headers browsers may read cross-origin.
+const exposedHeaders = "X-Request-Id, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset"
+
+// CORSPolicy describes which cross-origin callers may use the API. An
+// AllowedOrigins entry of "*" allows any origin.
+type CORSPolicy struct {
+	AllowedOrigins []string
+	AllowedHeaders string
+}
+
+func (p CORSPolicy) allowedOrigin(origin string) (string, bool) {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			return "*", true
+		}
+
+		if strings.EqualFold(allowed, origin) {
+			return origin, true
+		}
+	}
+
+	return "", false
+}
+
+// Wrap adds the CORS headers for allowed origins and answers preflight
+// requests without reaching handler.
+func (p CORSPolicy) Wrap(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// unless any origin is allowed, the responses depend on the origin,
+		// including those to requests without one the CDN may cache
+		if !slices.Contains(p.AllowedOrigins, "*") {
+			w.Header().Add("Vary", "Origin")
+		}
+
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			handler(w, r)
+			return
+		}
+
+		allowedOrigin, ok := p.allowedOrigin(origin)
+		if ok {
+			w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
+			w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+		}
+
+		isPreflight := r.Method == http.MethodOptions &&
+			r.Header.Get("Access-Control-Request-Method") != ""
+		if !isPreflight {
+			handler(w, r)
+			return
+		}
+
+		if ok {
+			allowedHeaders := p.AllowedHeaders
+			if allowedHeaders == "" {
+				allowedHeaders = defaultCORSHeaders
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			w.Header().Set("Access-Control-Max-Age", "86400")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSPolicy(t *testing.T) {
+	called := false
+	handler := CORSPolicy{AllowedOrigins: []string{"https://app.example"}}.Wrap(
+		func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		},
+	)
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://app.example")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if called {
+		t.Error("preflight requests should not reach the handler")
+	}
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("preflight status %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != defaultCORSHeaders {
+		t.Errorf("Access-Control-Allow-Headers = %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Error("simple requests should reach the handler")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("unexpected Access-Control-Allow-Origin %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rec.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("responses without an Origin should vary on it, got Vary %q", got)
+	}
+}
+
+func TestCORSPolicyWildcard(t *testing.T) {
+	handler := CORSPolicy{AllowedOrigins: []string{"*"}}.Wrap(
+		func(w http.ResponseWriter, r *http.Request) {},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://anything.example")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+}
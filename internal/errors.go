@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+var (
+	// ErrInvalidURL is returned for page URLs that are not absolute http(s)
+	// URLs.
+	ErrInvalidURL = errors.New("the url must be an absolute http(s) URL")
+
+	// ErrNotFound is returned when a fetched page has none of the content the
+	// scraper looks for, e.g. an expired bundle or a post without a recipe.
+	ErrNotFound = errors.New("no content found at the url")
+
+	// ErrUnsupportedSite is returned for URLs of sites the scraper does not
+	// know, which it never requests directly.
+	ErrUnsupportedSite = errors.New("the url is not on a supported site")
+
+	// ErrInvalidCategory is returned for unknown bundle categories.
+	ErrInvalidCategory = errors.New("invalid bundle category")
+
+	// ErrCircuitOpen is returned without fetching while the circuit of a
+	// failing source is open.
+	ErrCircuitOpen = errors.New("the source is failing, requests are paused")
+)
+
+// UpstreamError is a non-successful response from the rendering service.
+type UpstreamError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("upstream responded %d: %s", e.StatusCode, e.Body)
+}
+
+// Problem is an RFC 7807 problem details body. Code is a stable,
+// machine-readable identifier of the error.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+}
+
+// WriteProblem responds with an application/problem+json body.
+func WriteProblem(w http.ResponseWriter, status int, code string, detail string) {
+	body, _ := json.Marshal(
+		Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: detail,
+			Code:   code,
+		},
+	)
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// WriteError maps err to its status code and error code and responds with
+// the matching problem. The bodies of upstream responses are left out,
+// since they may come from any site a caller named.
+func WriteError(w http.ResponseWriter, err error) {
+	status, code := classifyError(err)
+
+	detail := err.Error()
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		detail = fmt.Sprintf("upstream responded %d", upstreamErr.StatusCode)
+	}
+
+	WriteProblem(w, status, code, detail)
+}
+
+func classifyError(err error) (int, string) {
+	var upstreamErr *UpstreamError
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, ErrInvalidURL):
+		return http.StatusUnprocessableEntity, "invalid_url"
+	case errors.Is(err, ErrUnsupportedSite):
+		return http.StatusUnprocessableEntity, "unsupported_site"
+	case errors.Is(err, ErrInvalidCategory):
+		return http.StatusUnprocessableEntity, "invalid_category"
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, ErrDisallowed):
+		return http.StatusForbidden, "disallowed_by_robots"
+	case errors.Is(err, ErrCircuitOpen):
+		return http.StatusServiceUnavailable, "source_unavailable"
+	case errors.As(err, &upstreamErr):
+		switch {
+		case upstreamErr.StatusCode == http.StatusTooManyRequests:
+			return http.StatusTooManyRequests, "upstream_rate_limited"
+		case upstreamErr.StatusCode == http.StatusUnauthorized ||
+			upstreamErr.StatusCode == http.StatusForbidden:
+			return http.StatusBadGateway, "upstream_unauthorized"
+		case upstreamErr.StatusCode >= http.StatusInternalServerError:
+			return http.StatusBadGateway, "upstream_error"
+		}
+
+		return http.StatusBadGateway, "upstream_bad_response"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return http.StatusServiceUnavailable, "upstream_unavailable"
+	}
+
+	return http.StatusInternalServerError, "internal"
+}
+
+// isUpstreamFailure reports whether err is a failure of the fetched site or
+// the route to it, rather than of the Browserless account or the caller.
+func isUpstreamFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrDisallowed) {
+		return false
+	}
+
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		switch upstreamErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+			return false
+		}
+	}
+
+	return true
+}
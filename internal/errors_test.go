@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{ErrInvalidURL, http.StatusUnprocessableEntity, "invalid_url"},
+		{ErrUnsupportedSite, http.StatusUnprocessableEntity, "unsupported_site"},
+		{fmt.Errorf("scraping: %w", ErrNotFound), http.StatusNotFound, "not_found"},
+		{&UpstreamError{StatusCode: 429}, http.StatusTooManyRequests, "upstream_rate_limited"},
+		{&UpstreamError{StatusCode: 401}, http.StatusBadGateway, "upstream_unauthorized"},
+		{&UpstreamError{StatusCode: 500}, http.StatusBadGateway, "upstream_error"},
+		{context.DeadlineExceeded, http.StatusServiceUnavailable, "upstream_unavailable"},
+		{fmt.Errorf("boom"), http.StatusInternalServerError, "internal"},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		WriteError(rec, test.err)
+
+		if rec.Code != test.status {
+			t.Errorf("%v: status %d, want %d", test.err, rec.Code, test.status)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+			t.Errorf("%v: Content-Type %q", test.err, got)
+		}
+
+		var problem Problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+			t.Fatal(err)
+		}
+		if problem.Code != test.code || problem.Status != test.status {
+			t.Errorf("%v: unexpected problem %+v", test.err, problem)
+		}
+	}
+}
+
+func TestWriteErrorHidesUpstreamBodies(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteError(rec, fmt.Errorf("scraping: %w", &UpstreamError{StatusCode: 500, Body: "db password"}))
+
+	var problem Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Detail != "upstream responded 500" {
+		t.Errorf("unexpected detail %q", problem.Detail)
+	}
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string    `xml:"id"`
+	Title     string    `xml:"title"`
+	Updated   time.Time `xml:"updated"`
+	Published time.Time `xml:"published"`
+	Link      atomLink  `xml:"link"`
+	Summary   string    `xml:"summary"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated time.Time   `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// BundlesFeed renders bundles as the Atom feed id served at self, newest
+// first, so feed readers pick up bundles as they go on sale. The self link
+// keeps the credentials readers need to fetch the feed, which the id,
+// naming the feed for good, leaves out.
+func BundlesFeed(title string, id string, self string, bundles []BundleListing) ([]byte, error) {
+	bundles = append([]BundleListing(nil), bundles...)
+	sort.SliceStable(
+		bundles, func(i, j int) bool {
+			return bundles[i].StartsAt.After(bundles[j].StartsAt)
+		},
+	)
+
+	feed := atomFeed{
+		ID:    id,
+		Title: title,
+		Links: []atomLink{
+			{Href: self, Rel: "self"},
+			{Href: bundlesIndexURL},
+		},
+	}
+
+	for _, bundle := range bundles {
+		if bundle.StartsAt.After(feed.Updated) {
+			feed.Updated = bundle.StartsAt
+		}
+
+		summary := fmt.Sprintf("Ends %s.", bundle.EndsAt.Format("2006-01-02"))
+		if bundle.Items > 0 {
+			summary = fmt.Sprintf("%d items. %s", bundle.Items, summary)
+		}
+
+		feed.Entries = append(
+			feed.Entries, atomEntry{
+				ID:        bundle.URL,
+				Title:     bundle.Name,
+				Updated:   bundle.StartsAt,
+				Published: bundle.StartsAt,
+				Link:      atomLink{Href: bundle.URL},
+				Summary:   summary,
+			},
+		)
+	}
+
+	// a feed is updated when its entries are, or now when it has none
+	if feed.Updated.IsZero() {
+		feed.Updated = time.Now().UTC().Truncate(time.Second)
+	}
+
+	content, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), content...), nil
+}
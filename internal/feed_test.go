@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBundlesFeed(t *testing.T) {
+	bundles, err := ListBundles(context.Background(), ReplayFetcher{Dir: "testdata"}, "books")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := BundlesFeed(
+		"Humble books bundles",
+		"https://scraper.example/feed",
+		"https://scraper.example/feed?apiKey=alice",
+		bundles,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := string(feed)
+	for _, want := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<updated>2024-05-09T18:00:00Z</updated>`,
+		`<id>https://scraper.example/feed</id>`,
+		`<link href="https://scraper.example/feed?apiKey=alice" rel="self"></link>`,
+		`<summary>18 items. Ends 2024-05-23.</summary>`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("feed missing %s:\n%s", want, content)
+		}
+	}
+
+	if strings.Index(content, "Learn to Code") > strings.Index(content, "Fantasy Worlds") {
+		t.Error("the newest bundle should come first")
+	}
+}
+
+func TestEmptyBundlesFeed(t *testing.T) {
+	feed, err := BundlesFeed("Humble Bundles", "https://scraper.example/feed", "https://scraper.example/feed", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(feed), "<updated>0001-01-01") {
+		t.Errorf("an empty feed should be updated now:\n%s", feed)
+	}
+}
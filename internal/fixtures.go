@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// RecordingFetcher saves every page returned by Fetcher into Dir, replacing
+// any occurrence of Secrets, so the pages can later be served by a
+// ReplayFetcher.
+type RecordingFetcher struct {
+	Fetcher Fetcher
+	Dir     string
+	Secrets []string
+}
+
+func (f RecordingFetcher) Fetch(ctx context.Context, url string) (
+	[]byte,
+	error,
+) {
+	content, err := f.Fetcher.Fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := content
+	for _, secret := range f.Secrets {
+		if secret == "" {
+			continue
+		}
+
+		recorded = bytes.ReplaceAll(recorded, []byte(secret), []byte(redacted))
+	}
+
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile(filepath.Join(f.Dir, FixtureName(url)), recorded, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// ReplayFetcher serves pages previously saved by a RecordingFetcher.
+type ReplayFetcher struct {
+	Dir string
+}
+
+func (f ReplayFetcher) Fetch(_ context.Context, url string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(f.Dir, FixtureName(url)))
+}
+
+// FixtureName maps a page URL to the file name its content is recorded
+// under, e.g. "https://thewoksoflife.com/kung-pao-chicken/" becomes
+// "thewoksoflife.com-kung-pao-chicken.html".
+func FixtureName(rawURL string) string {
+	name := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		name = u.Host + u.Path
+	}
+
+	name = regexp.MustCompile(`[^a-zA-Z0-9.]+`).ReplaceAllString(name, "-")
+
+	return strings.Trim(name, "-.") + ".html"
+}
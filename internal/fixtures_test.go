@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type stubFetcher map[string]string
+
+func (f stubFetcher) Fetch(_ context.Context, url string) ([]byte, error) {
+	content, ok := f[url]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+
+	return []byte(content), nil
+}
+
+// routePublicClient sends the requests of publicClient to server, whatever
+// their host, for the duration of the test.
+func routePublicClient(t *testing.T, server *httptest.Server) {
+	previous := publicClient
+	t.Cleanup(func() { publicClient = previous })
+
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network string, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+	}
+
+	publicClient = &http.Client{
+		Transport:     transport,
+		CheckRedirect: previous.CheckRedirect,
+	}
+}
+
+func TestFixtureName(t *testing.T) {
+	tests := map[string]string{
+		"https://thewoksoflife.com/kung-pao-chicken/":             "thewoksoflife.com-kung-pao-chicken.html",
+		"https://www.humblebundle.com/books/fantasy?hmb_source=x": "www.humblebundle.com-books-fantasy.html",
+	}
+
+	for url, expected := range tests {
+		if got := FixtureName(url); got != expected {
+			t.Errorf("FixtureName(%q) = %q, want %q", url, got, expected)
+		}
+	}
+}
+
+func TestRecordingFetcherStripsSecrets(t *testing.T) {
+	dir := t.TempDir()
+	url := "https://example.com/page"
+	fetcher := RecordingFetcher{
+		Fetcher: stubFetcher{url: "<p>token=s3cr3t</p>"},
+		Dir:     dir,
+		Secrets: []string{"s3cr3t"},
+	}
+
+	content, err := fetcher.Fetch(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "<p>token=s3cr3t</p>" {
+		t.Errorf("unexpected content %q", content)
+	}
+
+	recorded, err := os.ReadFile(filepath.Join(dir, FixtureName(url)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(recorded) != "<p>token=REDACTED</p>" {
+		t.Errorf("unexpected recording %q", recorded)
+	}
+
+	replayed, err := ReplayFetcher{Dir: dir}.Fetch(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(replayed) != string(recorded) {
+		t.Errorf("replay returned %q, want %q", replayed, recorded)
+	}
+}
+
+// fixtureFetcher serves the recorded page of url from memory, so that
+// benchmarks do not measure reading the fixture.
+func fixtureFetcher(b *testing.B, url string) stubFetcher {
+	b.Helper()
+
+	content, err := ReplayFetcher{Dir: "testdata"}.Fetch(context.Background(), url)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return stubFetcher{url: string(content)}
+}
@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const readinessTimeout = 5 * time.Second
+
+// Check is a named readiness probe of a dependency.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// HealthReport is the body of the health endpoints.
+type HealthReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+	// Circuits is the circuit breaker state of each scraped site in this
+	// instance. An open circuit does not make the service unready.
+	Circuits map[string]string `json:"circuits,omitempty"`
+}
+
+// RunChecks runs every check, reporting "ok" or the error of each.
+func RunChecks(ctx context.Context, checks []Check) (HealthReport, bool) {
+	report := HealthReport{Status: "ok", Checks: map[string]string{}}
+	healthy := true
+
+	for _, check := range checks {
+		if err := check.Run(ctx); err != nil {
+			report.Checks[check.Name] = err.Error()
+			healthy = false
+			continue
+		}
+
+		report.Checks[check.Name] = "ok"
+	}
+
+	if !healthy {
+		report.Status = "unavailable"
+	}
+
+	return report, healthy
+}
+
+// pinger is implemented by cache stores backed by a remote server.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// checkBrowserless verifies the Browserless API answers. With a
+// server-side token its pressure endpoint is queried, which also validates
+// the token; otherwise any non-5xx response counts as reachable.
+func checkBrowserless(ctx context.Context, token string) error {
+	endpoint := browserlessURL + "/"
+	if token != "" {
+		endpoint = browserlessURL + "/pressure?token=" + token
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("browserless unreachable: %w", withoutURL(err))
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError ||
+		(token != "" && resp.StatusCode >= http.StatusBadRequest) {
+		return fmt.Errorf("browserless responded %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func writeHealthReport(w http.ResponseWriter, report HealthReport, healthy bool) {
+	body, _ := json.Marshal(report)
+
+	status := http.StatusOK
+	if !healthy {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// Healthz reports that the function is running.
+func Healthz(w http.ResponseWriter, _ *http.Request) {
+	writeHealthReport(w, HealthReport{Status: "ok"}, true)
+}
+
+// Readyz reports whether the dependencies in checks are usable, answering
+// 503 when any of them is not, along with the circuits of breaker, if any.
+func Readyz(checks []Check, breaker *CircuitBreaker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		report, healthy := RunChecks(ctx, checks)
+		if breaker != nil {
+			report.Circuits = breaker.States()
+		}
+		writeHealthReport(w, report, healthy)
+	}
+}
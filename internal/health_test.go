@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadyz(t *testing.T) {
+	healthy := Check{Name: "cache", Run: func(context.Context) error { return nil }}
+	failing := Check{
+		Name: "browserless",
+		Run:  func(context.Context) error { return errors.New("unreachable") },
+	}
+
+	tests := []struct {
+		checks []Check
+		status int
+		report HealthReport
+	}{
+		{
+			[]Check{healthy},
+			http.StatusOK,
+			HealthReport{Status: "ok", Checks: map[string]string{"cache": "ok"}},
+		},
+		{
+			[]Check{healthy, failing},
+			http.StatusServiceUnavailable,
+			HealthReport{
+				Status: "unavailable",
+				Checks: map[string]string{"cache": "ok", "browserless": "unreachable"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		Readyz(test.checks, nil)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		if rec.Code != test.status {
+			t.Errorf("status %d, want %d", rec.Code, test.status)
+		}
+
+		var report HealthReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		if report.Status != test.report.Status || len(report.Checks) != len(test.report.Checks) {
+			t.Errorf("unexpected report %+v", report)
+		}
+		for name, result := range test.report.Checks {
+			if report.Checks[name] != result {
+				t.Errorf("check %s = %q, want %q", name, report.Checks[name], result)
+			}
+		}
+	}
+}
+
+func TestReadyzCircuits(t *testing.T) {
+	breaker := &CircuitBreaker{Threshold: 1, Cooldown: time.Minute}
+	breaker.Report("humblebundle", &UpstreamError{StatusCode: http.StatusForbidden})
+
+	rec := httptest.NewRecorder()
+	Readyz(nil, breaker)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report HealthReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || report.Circuits["humblebundle"] != "open" {
+		t.Errorf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"status":"ok"}` {
+		t.Errorf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const bundlesIndexURL = "https://www.humblebundle.com/bundles"
+
+// BundleCategories are the sections of the bundles index.
+var BundleCategories = []string{"books", "games", "software"}
+
+// humbleTime is the layout of the dates of the bundles index, in UTC.
+const humbleTime = "2006-01-02T15:04:05"
+
+// BundleListing is a bundle currently on sale.
+type BundleListing struct {
+	Name     string    `json:"name" yaml:"name" xml:"name"`
+	Slug     string    `json:"slug" yaml:"slug" xml:"slug"`
+	URL      string    `json:"url" yaml:"url" xml:"url"`
+	Category string    `json:"category" yaml:"category" xml:"category"`
+	StartsAt time.Time `json:"startsAt" yaml:"startsAt" xml:"startsAt"`
+	EndsAt   time.Time `json:"endsAt" yaml:"endsAt" xml:"endsAt"`
+	// Items is the item count advertised on the index, 0 when it is not.
+	Items int `json:"items" yaml:"items" xml:"items"`
+}
+
+// BundleIndex is a list of bundles, for the formats that need a root
+// element.
+type BundleIndex struct {
+	XMLName xml.Name        `json:"-" yaml:"-" xml:"bundles"`
+	Bundles []BundleListing `json:"bundles" yaml:"bundles" xml:"bundle"`
+}
+
+var itemCount = regexp.MustCompile(`^(\d+)\+? items?\b`)
+
+// CheckBundleCategory returns ErrInvalidCategory unless category is one of
+// BundleCategories or empty, for every category.
+func CheckBundleCategory(category string) error {
+	if category != "" && !isBundleCategory(category) {
+		return fmt.Errorf(
+			"%w: the category must be one of %s",
+			ErrInvalidCategory,
+			strings.Join(BundleCategories, ", "),
+		)
+	}
+
+	return nil
+}
+
+// FilterBundles returns the bundles in category, or all of them when it is
+// empty. Every category is listed on the same index page, so the bundles of
+// several categories are best filtered from a single listing of all of
+// them.
+func FilterBundles(bundles []BundleListing, category string) []BundleListing {
+	if category == "" {
+		return bundles
+	}
+
+	var filtered []BundleListing
+	for _, bundle := range bundles {
+		if bundle.Category == category {
+			filtered = append(filtered, bundle)
+		}
+	}
+
+	return filtered
+}
+
+// ListBundles scrapes the bundles on sale in category, one of
+// BundleCategories, or in every category when it is empty. The index does
+// not show price tiers, which are only on each bundle page.
+func ListBundles(ctx context.Context, fetcher Fetcher, category string) (
+	[]BundleListing,
+	error,
+) {
+	if err := CheckBundleCategory(category); err != nil {
+		return nil, err
+	}
+
+	htmlContent, err := fetcher.Fetch(ctx, bundlesIndexURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := tracer.Start(ctx, "parse")
+	defer span.End()
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(htmlContent))
+	if err != nil {
+		return nil, err
+	}
+
+	var landingPage struct {
+		Data map[string]struct {
+			Mosaic []struct {
+				Products []struct {
+					TileName   string   `json:"tile_name"`
+					ProductURL string   `json:"product_url"`
+					Category   string   `json:"category"`
+					StartDate  string   `json:"start_date|datetime"`
+					EndDate    string   `json:"end_date|datetime"`
+					Highlights []string `json:"highlights"`
+				} `json:"products"`
+			} `json:"mosaic"`
+		} `json:"data"`
+	}
+
+	script := doc.Find("script#landingPage-json-data").First().Text()
+	if script == "" {
+		return nil, ErrNotFound
+	}
+	if err := json.Unmarshal([]byte(script), &landingPage); err != nil {
+		return nil, fmt.Errorf("parsing the bundles index: %w", err)
+	}
+
+	var listings []BundleListing
+	traceField(
+		ctx, "bundles", func() {
+			for _, section := range BundleCategories {
+				for _, mosaic := range landingPage.Data[section].Mosaic {
+					for _, product := range mosaic.Products {
+						if product.Category != "bundle" {
+							continue
+						}
+
+						listing := BundleListing{
+							Name:     product.TileName,
+							Slug:     path.Base(product.ProductURL),
+							URL:      "https://www.humblebundle.com" + product.ProductURL,
+							Category: section,
+						}
+						listing.StartsAt, _ = time.Parse(humbleTime, product.StartDate)
+						listing.EndsAt, _ = time.Parse(humbleTime, product.EndDate)
+
+						for _, highlight := range product.Highlights {
+							if match := itemCount.FindStringSubmatch(strings.ToLower(highlight)); match != nil {
+								listing.Items, _ = strconv.Atoi(match[1])
+							}
+						}
+
+						listings = append(listings, listing)
+					}
+				}
+			}
+		},
+	)
+
+	return FilterBundles(listings, category), nil
+}
+
+func isBundleCategory(category string) bool {
+	for _, known := range BundleCategories {
+		if category == known {
+			return true
+		}
+	}
+
+	return false
+}
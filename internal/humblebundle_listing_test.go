@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestListBundles(t *testing.T) {
+	bundles, err := ListBundles(context.Background(), ReplayFetcher{Dir: "testdata"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, "humblebundle-bundles", bundles)
+}
+
+func TestListBundlesCategory(t *testing.T) {
+	bundles, err := ListBundles(context.Background(), ReplayFetcher{Dir: "testdata"}, "games")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bundles) != 1 || bundles[0].Slug != "cozy-games" || bundles[0].Items != 8 {
+		t.Errorf("unexpected bundles %+v", bundles)
+	}
+
+	if _, err := ListBundles(context.Background(), stubFetcher{}, "music"); !errors.Is(err, ErrInvalidCategory) {
+		t.Errorf("err = %v, want ErrInvalidCategory", err)
+	}
+}
+
+func TestFilterBundles(t *testing.T) {
+	bundles := []BundleListing{{Slug: "a", Category: "books"}, {Slug: "b", Category: "games"}}
+
+	if got := FilterBundles(bundles, "games"); len(got) != 1 || got[0].Slug != "b" {
+		t.Errorf("unexpected games %+v", got)
+	}
+	if got := FilterBundles(bundles, ""); len(got) != 2 {
+		t.Errorf("every bundle should be kept, got %+v", got)
+	}
+}
@@ -2,40 +2,56 @@ package internal
 
 import (
 	"bytes"
-	"fmt"
+	"context"
+	"encoding/xml"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
 type Bundle struct {
-	Name  string
-	Items []string
+	XMLName xml.Name `json:"-" yaml:"-" xml:"bundle"`
+	Name    string   `json:"name" yaml:"name" xml:"name"`
+	Items   []string `json:"items" yaml:"items" xml:"items>item"`
+	// Archived is the snapshot scraped instead of an expired bundle page.
+	Archived *Snapshot `json:"archived,omitempty" yaml:"archived,omitempty" xml:"archived,omitempty"`
 }
 
-func GetBundleData(browserlessToken string, url string) (
+func GetBundleData(ctx context.Context, fetcher Fetcher, url string) (
 	Bundle,
 	error,
 ) {
-	htmlContent, err := GrabContent(browserlessToken, url)
+	htmlContent, err := fetcher.Fetch(ctx, url)
 	if err != nil {
 		return Bundle{}, err
 	}
 
+	ctx, span := tracer.Start(ctx, "parse")
+	defer span.End()
+
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(htmlContent))
 	if err != nil {
 		return Bundle{}, err
 	}
 
-	bundleName, _ := doc.Find(".bundle-logo").First().Attr("alt")
+	var bundleName string
+	traceField(
+		ctx, "name", func() {
+			bundleName, _ = doc.Find(".bundle-logo").First().Attr("alt")
+		},
+	)
 
 	var itemNames []string
-
-	items := doc.Find(".item-title")
-	for _, bundleItem := range items.Nodes {
-		itemNames = append(
-			itemNames,
-			fmt.Sprintf("- %s", bundleItem.FirstChild.Data),
-		)
+	traceField(
+		ctx, "items", func() {
+			items := doc.Find(".item-title")
+			for _, bundleItem := range items.Nodes {
+				itemNames = append(itemNames, bundleItem.FirstChild.Data)
+			}
+		},
+	)
+
+	if bundleName == "" && len(itemNames) == 0 {
+		return Bundle{}, ErrNotFound
 	}
 
 	return Bundle{
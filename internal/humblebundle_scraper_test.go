@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGetBundleData(t *testing.T) {
+	bundle, err := GetBundleData(
+		context.Background(),
+		ReplayFetcher{Dir: "testdata"},
+		"https://www.humblebundle.com/books/fantasy-worlds-books",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, "humblebundle-fantasy-worlds-books", bundle)
+}
+
+func TestGetBundleDataNotFound(t *testing.T) {
+	url := "https://www.humblebundle.com/books/expired"
+	_, err := GetBundleData(
+		context.Background(),
+		stubFetcher{url: "<html><body><h1>Page not found</h1></body></html>"},
+		url,
+	)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func BenchmarkGetBundleData(b *testing.B) {
+	url := "https://www.humblebundle.com/books/fantasy-worlds-books"
+	fetcher := fixtureFetcher(b, url)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := GetBundleData(ctx, fetcher, url); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
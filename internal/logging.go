@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"humblebundle-scraper/internal/config"
+)
+
+const maxRequestIDLength = 64
+
+// newLogger builds the process logger from the log configuration.
+func newLogger(cfg config.Log) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+
+	if strings.EqualFold(cfg.Format, "json") {
+		return slog.New(slog.NewJSONHandler(os.Stderr, options))
+	}
+
+	return slog.New(slog.NewTextHandler(os.Stderr, options))
+}
+
+// LoggerFrom returns the request-scoped logger stored in ctx by Logged, or
+// the default logger outside of a request.
+func LoggerFrom(ctx context.Context) *slog.Logger {
+	if requestLogger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return requestLogger
+	}
+
+	return slog.Default()
+}
+
+func newRequestID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// Logged tags each request with an ID, taken from the X-Request-Id header
+// when the caller sends one, echoes it back in the response and logs the
+// outcome of the request.
+func Logged(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-Id")
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+		w.Header().Set("X-Request-Id", requestID)
+
+		requestLogger := slog.Default().With("request_id", requestID)
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.HasTraceID() {
+			requestLogger = requestLogger.With(
+				"trace_id",
+				spanContext.TraceID().String(),
+			)
+		}
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(
+			recorder,
+			r.WithContext(context.WithValue(r.Context(), loggerKey, requestLogger)),
+		)
+
+		requestLogger.Info(
+			"request handled",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"duration", time.Since(start),
+		)
+	}
+}
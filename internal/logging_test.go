@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoggedRequestID(t *testing.T) {
+	handler := Logged(
+		func(w http.ResponseWriter, r *http.Request) {
+			if LoggerFrom(r.Context()) == slog.Default() {
+				t.Error("expected a request-scoped logger")
+			}
+			w.WriteHeader(http.StatusNoContent)
+		},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/humblebundle", nil)
+	req.Header.Set("X-Request-Id", "abc123")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if got := rec.Header().Get("X-Request-Id"); got != "abc123" {
+		t.Errorf("X-Request-Id = %q, want %q", got, "abc123")
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/humblebundle", nil))
+
+	if got := rec.Header().Get("X-Request-Id"); len(got) != 16 {
+		t.Errorf("expected a generated request ID, got %q", got)
+	}
+}
@@ -0,0 +1,268 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Monitor notifies of the bundles put on sale since its last poll.
+type Monitor struct {
+	Fetcher    Fetcher
+	Notifier   Notifier
+	Categories []string
+	// StateFile, when set, keeps the bundles already seen across restarts.
+	StateFile string
+
+	seen map[string]bool
+	// delivered records, by bundle URL, the channels already notified of
+	// the bundles some channels failed to be notified of.
+	delivered map[string]map[int]bool
+	// failing is set while the polls fail, so a failure is notified once.
+	failing bool
+}
+
+// Run polls every interval until ctx is done. Failed polls are logged and
+// retried at the next tick.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.Poll(ctx); err != nil && ctx.Err() == nil {
+			LoggerFrom(ctx).Error("polling bundles failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll lists the bundles on sale and notifies of the ones not seen before,
+// which it returns, or of the failure to list them. The first poll without
+// a state only records the bundles on sale.
+func (m *Monitor) Poll(ctx context.Context) ([]BundleListing, error) {
+	seeding := false
+	if m.seen == nil {
+		var (
+			stored bool
+			err    error
+		)
+		if m.seen, stored, err = m.loadState(); err != nil {
+			return nil, err
+		}
+
+		// a stored state, even an empty one, was seeded already
+		seeding = !stored
+	}
+
+	for _, category := range m.Categories {
+		if err := CheckBundleCategory(category); err != nil {
+			return nil, err
+		}
+	}
+
+	// a single fetch of the index lists every category
+	bundles, err := ListBundles(ctx, m.Fetcher, "")
+	if err != nil {
+		if ctx.Err() == nil {
+			m.notifyFailure(ctx, err)
+		}
+		return nil, err
+	}
+
+	if m.failing {
+		LoggerFrom(ctx).Info("polling bundles recovered")
+		m.failing = false
+	}
+
+	current := map[string]bool{}
+	var fresh []BundleListing
+	for _, category := range m.Categories {
+		for _, bundle := range FilterBundles(bundles, category) {
+			current[bundle.URL] = true
+			if !m.seen[bundle.URL] {
+				fresh = append(fresh, bundle)
+			}
+		}
+	}
+
+	var errs []error
+	for _, bundle := range fresh {
+		if !seeding {
+			if err := m.notify(ctx, bundle.URL, m.message(ctx, bundle)); err != nil {
+				// retried at the next poll
+				errs = append(errs, fmt.Errorf("notifying %s: %w", bundle.Slug, err))
+				continue
+			}
+		}
+
+		m.seen[bundle.URL] = true
+	}
+
+	// forget the bundles no longer on sale
+	for url := range m.seen {
+		if !current[url] {
+			delete(m.seen, url)
+		}
+	}
+	for url := range m.delivered {
+		if !current[url] {
+			delete(m.delivered, url)
+		}
+	}
+
+	if seeding {
+		LoggerFrom(ctx).Info("bundles on sale recorded", "bundles", len(fresh))
+		fresh = nil
+	}
+
+	if err := m.saveState(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return fresh, errors.Join(errs...)
+}
+
+// notify sends message about the bundle at url to the channels of Notifier
+// that were not sent it yet, so retries do not send duplicates to the
+// channels that succeeded.
+func (m *Monitor) notify(ctx context.Context, url string, message Message) error {
+	channels, ok := m.Notifier.(Notifiers)
+	if !ok {
+		channels = Notifiers{m.Notifier}
+	}
+
+	if m.delivered == nil {
+		m.delivered = map[string]map[int]bool{}
+	}
+
+	delivered := m.delivered[url]
+	if delivered == nil {
+		delivered = map[int]bool{}
+		m.delivered[url] = delivered
+	}
+
+	var errs []error
+	for i, channel := range channels {
+		if delivered[i] {
+			continue
+		}
+
+		if err := channel.Notify(ctx, message); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		delivered[i] = true
+	}
+
+	if len(errs) == 0 {
+		delete(m.delivered, url)
+	}
+
+	return errors.Join(errs...)
+}
+
+// message summarizes bundle with its items, when its page can be scraped.
+func (m *Monitor) message(ctx context.Context, bundle BundleListing) Message {
+	message := Message{
+		Event: EventNewBundle,
+		Title: fmt.Sprintf("New Humble Bundle: %s", bundle.Name),
+		URL:   bundle.URL,
+	}
+
+	if !bundle.EndsAt.IsZero() {
+		message.Body = fmt.Sprintf("Ends %s.", bundle.EndsAt.Format("2006-01-02"))
+	}
+
+	details, err := GetBundleData(ctx, m.Fetcher, bundle.URL)
+	if err != nil {
+		LoggerFrom(ctx).Warn("scraping new bundle failed", "url", bundle.URL, "error", err)
+		return message
+	}
+
+	items := make([]string, len(details.Items))
+	for i, item := range details.Items {
+		items[i] = "- " + item
+	}
+
+	message.Body = strings.TrimSpace(
+		fmt.Sprintf("%s %d items:\n%s", message.Body, len(items), strings.Join(items, "\n")),
+	)
+
+	return message
+}
+
+// notifyFailure notifies of a failure to list the bundles on sale, unless
+// the previous poll failed too: while a source is down, e.g. with its
+// circuit open, every poll fails the same way.
+func (m *Monitor) notifyFailure(ctx context.Context, err error) {
+	if m.failing {
+		return
+	}
+	m.failing = true
+
+	message := Message{
+		Event: failureEvent(err),
+		Title: "Polling Humble Bundles failed",
+		Body:  err.Error(),
+		URL:   bundlesIndexURL,
+	}
+	if err := m.Notifier.Notify(ctx, message); err != nil {
+		LoggerFrom(ctx).Error("notifying the failure failed", "error", err)
+	}
+}
+
+// loadState returns the bundles seen before and whether a state was
+// stored.
+func (m *Monitor) loadState() (map[string]bool, bool, error) {
+	seen := map[string]bool{}
+	if m.StateFile == "" {
+		return seen, false, nil
+	}
+
+	content, err := os.ReadFile(m.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return seen, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var urls []string
+	if err := json.Unmarshal(content, &urls); err != nil {
+		return nil, false, fmt.Errorf("monitor state %s: %w", m.StateFile, err)
+	}
+
+	for _, url := range urls {
+		seen[url] = true
+	}
+
+	return seen, true, nil
+}
+
+func (m *Monitor) saveState() error {
+	if m.StateFile == "" {
+		return nil
+	}
+
+	urls := make([]string, 0, len(m.seen))
+	for url := range m.seen {
+		urls = append(urls, url)
+	}
+
+	content, err := json.Marshal(urls)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(m.StateFile, content, 0o644)
+}
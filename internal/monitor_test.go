@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type recordingNotifier struct {
+	messages []Message
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, message Message) error {
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+func bundlesIndex(slugs ...string) string {
+	products := make([]string, len(slugs))
+	for i, slug := range slugs {
+		products[i] = `{"tile_name": "` + slug + `", "product_url": "/books/` + slug + `", "category": "bundle", "end_date|datetime": "2024-05-23T18:00:00"}`
+	}
+
+	return `<script id="landingPage-json-data" type="application/json">{"data": {"books": {"mosaic": [{"products": [` +
+		strings.Join(products, ",") + `]}]}}}</script>`
+}
+
+func TestMonitorPoll(t *testing.T) {
+	fetcher := stubFetcher{bundlesIndexURL: bundlesIndex("old")}
+	fetcher["https://www.humblebundle.com/books/new"] =
+		`<img class="bundle-logo" alt="New"/><span class="item-title">Dune</span>`
+	notifier := &recordingNotifier{}
+	state := filepath.Join(t.TempDir(), "seen.json")
+
+	monitor := &Monitor{
+		Fetcher:    fetcher,
+		Notifier:   notifier,
+		Categories: []string{"books"},
+		StateFile:  state,
+	}
+
+	if fresh, err := monitor.Poll(context.Background()); err != nil || len(fresh) != 0 {
+		t.Fatalf("the first poll should only record the bundles, got %v, %v", fresh, err)
+	}
+
+	fetcher[bundlesIndexURL] = bundlesIndex("old", "new")
+	fresh, err := monitor.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fresh) != 1 || fresh[0].Slug != "new" || len(notifier.messages) != 1 {
+		t.Fatalf("unexpected new bundles %+v", fresh)
+	}
+
+	message := notifier.messages[0]
+	if message.Title != "New Humble Bundle: new" ||
+		message.Body != "Ends 2024-05-23. 1 items:\n- Dune" {
+		t.Errorf("unexpected message %+v", message)
+	}
+
+	// a restarted monitor resumes from the state file
+	restarted := &Monitor{
+		Fetcher:    fetcher,
+		Notifier:   notifier,
+		Categories: []string{"books"},
+		StateFile:  state,
+	}
+	if fresh, err := restarted.Poll(context.Background()); err != nil || len(fresh) != 0 {
+		t.Errorf("got %v, %v, want no new bundles", fresh, err)
+	}
+
+	content, _ := os.ReadFile(state)
+	if !strings.Contains(string(content), "/books/new") {
+		t.Errorf("unexpected state %s", content)
+	}
+}
+
+func TestMonitorPollEmptyState(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "seen.json")
+	if err := os.WriteFile(state, []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &recordingNotifier{}
+	monitor := &Monitor{
+		Fetcher:    stubFetcher{bundlesIndexURL: bundlesIndex("new")},
+		Notifier:   notifier,
+		Categories: []string{"books"},
+		StateFile:  state,
+	}
+
+	if fresh, _ := monitor.Poll(context.Background()); len(fresh) != 1 || len(notifier.messages) != 1 {
+		t.Errorf("a stored empty state should not be seeded again, got %+v", fresh)
+	}
+}
+
+func TestMonitorPollFailure(t *testing.T) {
+	notifier := &recordingNotifier{}
+	monitor := &Monitor{
+		Fetcher:    stubFetcher{},
+		Notifier:   notifier,
+		Categories: []string{"books"},
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := monitor.Poll(context.Background()); err == nil {
+			t.Fatal("expected the poll to fail")
+		}
+	}
+
+	if len(notifier.messages) != 1 || notifier.messages[0].Event != EventScrapeFailed {
+		t.Errorf("want a single failure message, got %+v", notifier.messages)
+	}
+
+	// a recovered monitor notifies of the next failure again
+	monitor.Fetcher = stubFetcher{bundlesIndexURL: bundlesIndex()}
+	_, _ = monitor.Poll(context.Background())
+	monitor.Fetcher = stubFetcher{}
+	_, _ = monitor.Poll(context.Background())
+
+	if len(notifier.messages) != 2 {
+		t.Errorf("want the new failure notified, got %+v", notifier.messages)
+	}
+}
+
+type countingFetcher struct {
+	Fetcher
+	fetches map[string]int
+}
+
+func (f countingFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	f.fetches[url]++
+	return f.Fetcher.Fetch(ctx, url)
+}
+
+func TestMonitorFetchesTheIndexOnce(t *testing.T) {
+	fetcher := countingFetcher{
+		Fetcher: stubFetcher{bundlesIndexURL: bundlesIndex("old")},
+		fetches: map[string]int{},
+	}
+	monitor := &Monitor{
+		Fetcher:    fetcher,
+		Notifier:   &recordingNotifier{},
+		Categories: []string{"books", "games", "software"},
+	}
+
+	if _, err := monitor.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := fetcher.fetches[bundlesIndexURL]; n != 1 {
+		t.Errorf("the index was fetched %d times, want once", n)
+	}
+}
+
+type failingNotifier struct {
+	failures int
+	recordingNotifier
+}
+
+func (n *failingNotifier) Notify(ctx context.Context, message Message) error {
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("smtp: connection refused")
+	}
+
+	return n.recordingNotifier.Notify(ctx, message)
+}
+
+func TestMonitorRetriesFailedChannelsOnly(t *testing.T) {
+	fetcher := stubFetcher{bundlesIndexURL: bundlesIndex()}
+	working, failing := &recordingNotifier{}, &failingNotifier{failures: 1}
+	monitor := &Monitor{
+		Fetcher:    fetcher,
+		Notifier:   Notifiers{working, failing},
+		Categories: []string{"books"},
+	}
+
+	_, _ = monitor.Poll(context.Background())
+
+	fetcher[bundlesIndexURL] = bundlesIndex("new")
+	if _, err := monitor.Poll(context.Background()); err == nil {
+		t.Fatal("expected the failing channel's error")
+	}
+	if _, err := monitor.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(working.messages) != 1 || len(failing.messages) != 1 {
+		t.Errorf(
+			"got %d and %d messages, want one per channel",
+			len(working.messages),
+			len(failing.messages),
+		)
+	}
+}
@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a representation a handler can respond with.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+	FormatXML  Format = "xml"
+	FormatYAML Format = "yaml"
+)
+
+// formatsByMediaType maps text/html to FormatText so browsers, which accept
+// XML with a lower quality, keep getting the text output.
+var formatsByMediaType = map[string]Format{
+	"text/plain":         FormatText,
+	"text/html":          FormatText,
+	"text/markdown":      FormatText,
+	"application/json":   FormatJSON,
+	"application/xml":    FormatXML,
+	"text/xml":           FormatXML,
+	"application/yaml":   FormatYAML,
+	"application/x-yaml": FormatYAML,
+	"text/yaml":          FormatYAML,
+}
+
+var contentTypes = map[Format]string{
+	FormatText: "text/plain; charset=utf-8",
+	FormatJSON: "application/json; charset=utf-8",
+	FormatXML:  "application/xml; charset=utf-8",
+	FormatYAML: "application/yaml; charset=utf-8",
+}
+
+// NegotiateFormat picks the response format from the "format" query param
+// or, when absent, the Accept header. It defaults to FormatText and returns
+// false when the client only accepts unsupported formats.
+func NegotiateFormat(r *http.Request) (Format, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		_, ok := contentTypes[Format(format)]
+		return Format(format), ok
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return FormatText, true
+	}
+
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{mediaType, quality})
+		}
+	}
+
+	sort.SliceStable(
+		ranges, func(i, j int) bool {
+			return ranges[i].quality > ranges[j].quality
+		},
+	)
+
+	for _, accepted := range ranges {
+		if accepted.mediaType == "*/*" || accepted.mediaType == "text/*" {
+			return FormatText, true
+		}
+
+		if format, ok := formatsByMediaType[accepted.mediaType]; ok {
+			return format, true
+		}
+	}
+
+	return "", false
+}
+
+// ContentType returns the Content-Type header value for format.
+func ContentType(format Format) string {
+	return contentTypes[format]
+}
+
+// Marshal serializes v in one of the structured formats.
+func Marshal(format Format, v any) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.Marshal(v)
+	case FormatXML:
+		body, err := xml.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte(xml.Header), body...), nil
+	case FormatYAML:
+		return yaml.Marshal(v)
+	}
+
+	return nil, fmt.Errorf("format %q is not a structured format", format)
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		target string
+		accept string
+		format Format
+		ok     bool
+	}{
+		{"/", "", FormatText, true},
+		{"/", "*/*", FormatText, true},
+		{"/", "application/json", FormatJSON, true},
+		{"/", "text/html, application/xml;q=0.9, */*;q=0.8", FormatText, true},
+		{"/", "text/html;q=0.5, application/xml", FormatXML, true},
+		{"/", "application/json;q=0.5, application/yaml", FormatYAML, true},
+		{"/", "image/png", "", false},
+		{"/?format=json", "application/xml", FormatJSON, true},
+		{"/?format=opf", "", "opf", false},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.target, nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+
+		format, ok := NegotiateFormat(req)
+		if format != test.format || ok != test.ok {
+			t.Errorf(
+				"%s with Accept %q: got %q, %v; want %q, %v",
+				test.target, test.accept, format, ok, test.format, test.ok,
+			)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	bundle := Bundle{Name: "Fantasy", Items: []string{"Earthsea"}}
+
+	tests := map[Format]string{
+		FormatJSON: `{"name":"Fantasy","items":["Earthsea"]}`,
+		FormatXML:  `<bundle><name>Fantasy</name><items><item>Earthsea</item></items></bundle>`,
+		FormatYAML: "name: Fantasy\nitems:\n    - Earthsea\n",
+	}
+
+	for format, expected := range tests {
+		body, err := Marshal(format, bundle)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasSuffix(string(body), expected) {
+			t.Errorf("%s: got %s, want %s", format, body, expected)
+		}
+	}
+
+	if _, err := Marshal(FormatText, bundle); err == nil {
+		t.Error("text is not a structured format")
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	url := "https://thewoksoflife.com/kung-pao-chicken/"
+	recipe, err := GetRecipe(context.Background(), fixtureFetcher(b, url), url)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, format := range []Format{FormatJSON, FormatXML, FormatYAML} {
+		b.Run(
+			string(format), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := Marshal(format, recipe); err != nil {
+						b.Fatal(err)
+					}
+				}
+			},
+		)
+	}
+}
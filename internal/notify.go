@@ -0,0 +1,314 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// telegramAPIURL is the Telegram Bot API the bot tokens are appended to.
+var telegramAPIURL = "https://api.telegram.org/bot"
+
+// notifyTimeout bounds the delivery of a message to a channel, so one that
+// hangs does not stall the monitor.
+const notifyTimeout = 30 * time.Second
+
+// notifyClient posts the messages to the notification APIs.
+var notifyClient = &http.Client{Timeout: notifyTimeout}
+
+// Event is the kind of occurrence a message notifies of.
+type Event string
+
+const (
+	EventNewBundle    Event = "new_bundle"
+	EventScrapeFailed Event = "scrape_failed"
+	// EventBotDetected is a scrape refused with 403 or 429, which is how the
+	// scraped sites and providers turn bots away.
+	EventBotDetected Event = "bot_detected"
+)
+
+// Events are the events notifications can be sent for.
+var Events = []Event{EventNewBundle, EventScrapeFailed, EventBotDetected}
+
+// Message is a notification, rendered as plain text by every channel but
+// the generic webhook.
+type Message struct {
+	Event Event
+	Title string
+	Body  string
+	URL   string
+}
+
+func (m Message) text() string {
+	text := m.Title
+	if m.URL != "" {
+		text += "\n" + m.URL
+	}
+	if m.Body != "" {
+		text += "\n\n" + m.Body
+	}
+
+	return text
+}
+
+// Notifier sends messages to a channel.
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+}
+
+// Notifiers sends messages to every notifier, returning all their errors.
+type Notifiers []Notifier
+
+func (n Notifiers) Notify(ctx context.Context, message Message) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.Notify(ctx, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// failureEvent is the event a failed scrape is notified as.
+func failureEvent(err error) Event {
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		switch upstreamErr.StatusCode {
+		case http.StatusForbidden, http.StatusTooManyRequests:
+			return EventBotDetected
+		}
+	}
+
+	return EventScrapeFailed
+}
+
+// EventFilter sends Notifier the messages of Events only.
+type EventFilter struct {
+	Notifier Notifier
+	Events   []Event
+}
+
+func (f EventFilter) Notify(ctx context.Context, message Message) error {
+	for _, event := range f.Events {
+		if event == message.Event {
+			return f.Notifier.Notify(ctx, message)
+		}
+	}
+
+	return nil
+}
+
+// WebhookNotifier posts messages as JSON objects to a URL.
+type WebhookNotifier struct {
+	URL string
+}
+
+func (n WebhookNotifier) Notify(ctx context.Context, message Message) error {
+	return postJSON(
+		ctx,
+		"webhook",
+		n.URL,
+		map[string]string{
+			"event": string(message.Event),
+			"title": message.Title,
+			"body":  message.Body,
+			"url":   message.URL,
+		},
+	)
+}
+
+// SlackNotifier posts messages to a Slack incoming webhook.
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+func (n SlackNotifier) Notify(ctx context.Context, message Message) error {
+	return postJSON(
+		ctx,
+		"slack",
+		n.WebhookURL,
+		map[string]string{"text": message.text()},
+	)
+}
+
+// DiscordNotifier posts messages to a Discord channel webhook.
+type DiscordNotifier struct {
+	WebhookURL string
+}
+
+// discordMaxLength is the maximum length of a Discord message.
+const discordMaxLength = 2000
+
+func (n DiscordNotifier) Notify(ctx context.Context, message Message) error {
+	return postJSON(
+		ctx,
+		"discord",
+		n.WebhookURL,
+		map[string]string{
+			"content": truncate(message.text(), discordMaxLength-1),
+		},
+	)
+}
+
+// TelegramNotifier sends messages to a chat with a Telegram bot.
+type TelegramNotifier struct {
+	Token  string
+	ChatID string
+}
+
+// telegramMaxLength is the maximum length of a Telegram message.
+const telegramMaxLength = 4096
+
+func (n TelegramNotifier) Notify(ctx context.Context, message Message) error {
+	return postJSON(
+		ctx,
+		"telegram",
+		telegramAPIURL+n.Token+"/sendMessage",
+		map[string]string{
+			"chat_id": n.ChatID,
+			"text":    truncate(message.text(), telegramMaxLength-1),
+		},
+	)
+}
+
+// SMTPNotifier emails messages through an SMTP server, authenticating with
+// PLAIN auth when a username is set.
+type SMTPNotifier struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (n SMTPNotifier) Notify(ctx context.Context, message Message) error {
+	if err := n.send(ctx, n.email(message)); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+
+	return nil
+}
+
+// send is smtp.SendMail within the deadline of ctx, or notifyTimeout.
+func (n SMTPNotifier) send(ctx context.Context, email []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return err
+	}
+
+	// the deadline interrupts a server that stops answering, AfterFunc a
+	// canceled ctx
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	host, _, _ := strings.Cut(n.Addr, ":")
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.Username, n.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(n.From); err != nil {
+		return err
+	}
+	for _, to := range n.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := data.Write(email); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (n SMTPNotifier) email(message Message) []byte {
+	var email bytes.Buffer
+	email.WriteString("From: " + n.From + "\r\n")
+	email.WriteString("To: " + strings.Join(n.To, ", ") + "\r\n")
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(message.Title)
+	email.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	email.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	email.WriteString(strings.ReplaceAll(message.text(), "\n", "\r\n"))
+
+	return email.Bytes()
+}
+
+// postJSON posts payload to a notification API, turning unsuccessful
+// responses into UpstreamErrors.
+func postJSON(ctx context.Context, channel string, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		// the webhook and bot URLs are secrets
+		return fmt.Errorf("%s: %w", channel, withoutURL(err))
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf(
+			"%s: %w",
+			channel,
+			&UpstreamError{
+				StatusCode: resp.StatusCode,
+				Body:       truncate(string(respBody), maxUpstreamErrorLength),
+			},
+		)
+	}
+
+	return nil
+}
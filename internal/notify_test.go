@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiscordNotifier(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				var payload map[string]string
+				_ = json.NewDecoder(r.Body).Decode(&payload)
+
+				if payload["content"] != "New bundle\nhttps://example.com\n\n- Dune" {
+					t.Errorf("unexpected payload %v", payload)
+				}
+
+				w.WriteHeader(http.StatusNoContent)
+			},
+		),
+	)
+	defer server.Close()
+
+	err := DiscordNotifier{WebhookURL: server.URL}.Notify(
+		context.Background(),
+		Message{Title: "New bundle", URL: "https://example.com", Body: "- Dune"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTelegramNotifier(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				var payload map[string]string
+				_ = json.NewDecoder(r.Body).Decode(&payload)
+
+				if r.URL.Path != "/bottoken/sendMessage" || payload["chat_id"] != "42" {
+					t.Errorf("unexpected request %s %v", r.URL, payload)
+				}
+
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"ok": false, "description": "chat not found"}`))
+			},
+		),
+	)
+	defer server.Close()
+
+	previous := telegramAPIURL
+	telegramAPIURL = server.URL + "/bot"
+	defer func() { telegramAPIURL = previous }()
+
+	err := TelegramNotifier{Token: "token", ChatID: "42"}.Notify(
+		context.Background(),
+		Message{Title: "New bundle"},
+	)
+
+	var upstreamErr *UpstreamError
+	if !errors.As(err, &upstreamErr) || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("err = %v, want the Telegram error", err)
+	}
+}
+
+func TestSMTPEmail(t *testing.T) {
+	notifier := SMTPNotifier{From: "scraper@example.com", To: []string{"a@example.com", "b@example.com"}}
+
+	email := string(notifier.email(Message{Title: "New bundle", Body: "- Dune\n- Emma"}))
+	if !strings.Contains(email, "To: a@example.com, b@example.com\r\n") ||
+		!strings.Contains(email, "Subject: New bundle\r\n") ||
+		!strings.HasSuffix(email, "\r\n\r\nNew bundle\r\n\r\n- Dune\r\n- Emma") {
+		t.Errorf("unexpected email %q", email)
+	}
+
+	email = string(notifier.email(Message{Title: "Bücher\r\nBcc: x@example.com"}))
+	if !strings.Contains(email, "Subject: =?utf-8?q?B=C3=BCcher__Bcc:_x@example.com?=\r\n") {
+		t.Errorf("the subject should be a single encoded line, got %q", email)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("héllo", 2); got != "hé…" {
+		t.Errorf("truncate = %q, want whole runes", got)
+	}
+	if got := truncate("héllo", 5); got != "héllo" {
+		t.Errorf("truncate = %q, want the string untouched", got)
+	}
+}
+
+func TestSMTPNotifierHungServer(t *testing.T) {
+	// the server accepts the connection but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = SMTPNotifier{Addr: listener.Addr().String(), To: []string{"a@example.com"}}.Notify(
+		ctx,
+		Message{Title: "New bundle"},
+	)
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("err = %v after %s, want the deadline to interrupt the delivery", err, time.Since(start))
+	}
+}
+
+func TestPostJSONTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			},
+		),
+	)
+	defer server.Close()
+	defer close(release)
+
+	previous := notifyClient
+	notifyClient = &http.Client{Timeout: 50 * time.Millisecond}
+	defer func() { notifyClient = previous }()
+
+	err := DiscordNotifier{WebhookURL: server.URL}.Notify(context.Background(), Message{Title: "x"})
+	if err == nil {
+		t.Error("expected a hung webhook to time out")
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	first, second := &recordingNotifier{}, &recordingNotifier{}
+
+	if err := (Notifiers{first, second}).Notify(context.Background(), Message{Title: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(first.messages) != 1 || len(second.messages) != 1 {
+		t.Error("every notifier should be sent the message")
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				var payload map[string]string
+				_ = json.NewDecoder(r.Body).Decode(&payload)
+
+				if payload["event"] != "new_bundle" || payload["title"] != "New bundle" {
+					t.Errorf("unexpected payload %v", payload)
+				}
+			},
+		),
+	)
+	defer server.Close()
+
+	err := WebhookNotifier{URL: server.URL}.Notify(
+		context.Background(),
+		Message{Event: EventNewBundle, Title: "New bundle"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	notifier := &recordingNotifier{}
+	filter := EventFilter{Notifier: notifier, Events: []Event{EventBotDetected}}
+
+	_ = filter.Notify(context.Background(), Message{Event: EventNewBundle})
+	_ = filter.Notify(context.Background(), Message{Event: EventBotDetected})
+
+	if len(notifier.messages) != 1 || notifier.messages[0].Event != EventBotDetected {
+		t.Errorf("unexpected messages %+v", notifier.messages)
+	}
+}
+
+func TestFailureEvent(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Event
+	}{
+		{&UpstreamError{StatusCode: http.StatusForbidden}, EventBotDetected},
+		{&UpstreamError{StatusCode: http.StatusTooManyRequests}, EventBotDetected},
+		{&UpstreamError{StatusCode: http.StatusBadGateway}, EventScrapeFailed},
+		{ErrNotFound, EventScrapeFailed},
+	}
+
+	for _, test := range tests {
+		if got := failureEvent(test.err); got != test.want {
+			t.Errorf("failureEvent(%v) = %s, want %s", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"sync"
+
+	"humblebundle-scraper/internal/config"
+)
+
+// defaultProfiles are current desktop browsers, used instead of the
+// HeadlessChrome user agent sites readily block.
+var defaultProfiles = []config.BrowserProfile{
+	{
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		Headers: map[string]string{
+			"sec-ch-ua":          `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`,
+			"sec-ch-ua-mobile":   "?0",
+			"sec-ch-ua-platform": `"Windows"`,
+		},
+	},
+	{
+		UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		Headers: map[string]string{
+			"sec-ch-ua":          `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`,
+			"sec-ch-ua-mobile":   "?0",
+			"sec-ch-ua-platform": `"macOS"`,
+		},
+	},
+	{
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0",
+		Headers: map[string]string{
+			"sec-ch-ua":          `"Chromium";v="124", "Microsoft Edge";v="124", "Not-A.Brand";v="99"`,
+			"sec-ch-ua-mobile":   "?0",
+			"sec-ch-ua-platform": `"Windows"`,
+		},
+	},
+}
+
+// ProfileRotation hands out browser profiles in turn, each sent with the
+// AcceptLanguage header.
+type ProfileRotation struct {
+	Profiles       []config.BrowserProfile
+	AcceptLanguage string
+
+	mu   sync.Mutex
+	next int
+}
+
+// Next returns the next profile, with its headers copied so callers may
+// not alter the rotation.
+func (r *ProfileRotation) Next() config.BrowserProfile {
+	profiles := r.Profiles
+	if len(profiles) == 0 {
+		profiles = defaultProfiles
+	}
+
+	r.mu.Lock()
+	profile := profiles[r.next%len(profiles)]
+	r.next++
+	r.mu.Unlock()
+
+	headers := make(map[string]string, len(profile.Headers)+1)
+	for name, value := range profile.Headers {
+		headers[name] = value
+	}
+	if r.AcceptLanguage != "" {
+		headers["Accept-Language"] = r.AcceptLanguage
+	}
+
+	return config.BrowserProfile{UserAgent: profile.UserAgent, Headers: headers}
+}
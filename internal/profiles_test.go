@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"testing"
+
+	"humblebundle-scraper/internal/config"
+)
+
+func TestProfileRotation(t *testing.T) {
+	rotation := &ProfileRotation{
+		Profiles: []config.BrowserProfile{
+			{UserAgent: "first", Headers: map[string]string{"sec-ch-ua-mobile": "?0"}},
+			{UserAgent: "second"},
+		},
+		AcceptLanguage: "pt-BR,pt;q=0.9",
+	}
+
+	first := rotation.Next()
+	if first.UserAgent != "first" ||
+		first.Headers["sec-ch-ua-mobile"] != "?0" ||
+		first.Headers["Accept-Language"] != "pt-BR,pt;q=0.9" {
+		t.Errorf("unexpected profile %+v", first)
+	}
+
+	if second := rotation.Next(); second.UserAgent != "second" {
+		t.Errorf("unexpected profile %+v", second)
+	}
+	if third := rotation.Next(); third.UserAgent != "first" {
+		t.Errorf("the rotation should wrap around, got %+v", third)
+	}
+
+	if _, ok := rotation.Profiles[0].Headers["Accept-Language"]; ok {
+		t.Error("the configured profile was modified")
+	}
+}
+
+func TestProfileRotationDefaults(t *testing.T) {
+	rotation := &ProfileRotation{}
+
+	if profile := rotation.Next(); profile.UserAgent != defaultProfiles[0].UserAgent {
+		t.Errorf("expected the built-in profiles, got %+v", profile)
+	}
+}
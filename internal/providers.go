@@ -0,0 +1,183 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Names of the services able to render pages.
+const (
+	ProviderBrowserless = "browserless"
+	ProviderScrapingBee = "scrapingbee"
+	ProviderScraperAPI  = "scraperapi"
+	ProviderZyte        = "zyte"
+)
+
+var (
+	scrapingBeeURL = "https://app.scrapingbee.com/api/v1/"
+	scraperAPIURL  = "https://api.scraperapi.com/"
+	zyteURL        = "https://api.zyte.com/v1/extract"
+)
+
+// ScrapingBeeFetcher renders pages through the ScrapingBee API.
+type ScrapingBeeFetcher struct {
+	APIKey  string
+	Options map[string]string
+}
+
+func (f ScrapingBeeFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	params := neturl.Values{
+		"api_key":   {f.APIKey},
+		"url":       {url},
+		"render_js": {"true"},
+	}
+	for name, value := range f.Options {
+		params.Set(name, value)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		scrapingBeeURL+"?"+params.Encode(),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return providerRequest(ctx, ProviderScrapingBee, url, req)
+}
+
+// ScraperAPIFetcher renders pages through the ScraperAPI API.
+type ScraperAPIFetcher struct {
+	APIKey  string
+	Options map[string]string
+}
+
+func (f ScraperAPIFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	params := neturl.Values{
+		"api_key": {f.APIKey},
+		"url":     {url},
+		"render":  {"true"},
+	}
+	for name, value := range f.Options {
+		params.Set(name, value)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		scraperAPIURL+"?"+params.Encode(),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return providerRequest(ctx, ProviderScraperAPI, url, req)
+}
+
+// ZyteFetcher renders pages through the Zyte API. Its options are added to
+// the extraction request, with "true" and "false" sent as booleans.
+type ZyteFetcher struct {
+	APIKey  string
+	Options map[string]string
+}
+
+func (f ZyteFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	payload := map[string]any{
+		"url":         url,
+		"browserHtml": true,
+	}
+	for name, value := range f.Options {
+		if flag, err := strconv.ParseBool(value); err == nil {
+			payload[name] = flag
+			continue
+		}
+
+		payload[name] = value
+	}
+
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		zyteURL,
+		bytes.NewReader(reqBody),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(f.APIKey, "")
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := providerRequest(ctx, ProviderZyte, url, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var extracted struct {
+		BrowserHTML string `json:"browserHtml"`
+	}
+	if err := json.Unmarshal(body, &extracted); err != nil {
+		return nil, fmt.Errorf("zyte: %w", err)
+	}
+
+	return []byte(extracted.BrowserHTML), nil
+}
+
+// providerRequest sends req to a scraping API, tracing it and turning
+// unsuccessful responses into UpstreamErrors.
+func providerRequest(
+	ctx context.Context,
+	provider string,
+	url string,
+	req *http.Request,
+) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, provider+".content")
+	defer span.End()
+	span.SetAttributes(attribute.String("scraper.url", url))
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		err = withoutURL(err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		err = &UpstreamError{
+			StatusCode: resp.StatusCode,
+			Body:       truncate(string(body), maxUpstreamErrorLength),
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return body, nil
+}
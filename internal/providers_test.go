@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func stubProvider(t *testing.T, endpoint *string, handler http.HandlerFunc) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	previous := *endpoint
+	*endpoint = server.URL
+	t.Cleanup(func() { *endpoint = previous })
+}
+
+func TestScrapingBeeFetcher(t *testing.T) {
+	stubProvider(
+		t, &scrapingBeeURL, func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			if query.Get("api_key") != "key" ||
+				query.Get("url") != "https://example.com" ||
+				query.Get("render_js") != "true" ||
+				query.Get("country_code") != "us" {
+				t.Errorf("unexpected request %s", r.URL)
+			}
+
+			_, _ = w.Write([]byte("<p>bee</p>"))
+		},
+	)
+
+	fetcher := ScrapingBeeFetcher{APIKey: "key", Options: map[string]string{"country_code": "us"}}
+	content, err := fetcher.Fetch(context.Background(), "https://example.com")
+	if err != nil || string(content) != "<p>bee</p>" {
+		t.Errorf("got %q, %v", content, err)
+	}
+}
+
+func TestScraperAPIFetcherError(t *testing.T) {
+	stubProvider(
+		t, &scraperAPIURL, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("render") != "true" {
+				t.Errorf("unexpected request %s", r.URL)
+			}
+
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("out of credits"))
+		},
+	)
+
+	_, err := ScraperAPIFetcher{APIKey: "key"}.Fetch(context.Background(), "https://example.com")
+
+	var upstreamErr *UpstreamError
+	if !errors.As(err, &upstreamErr) || upstreamErr.StatusCode != http.StatusForbidden {
+		t.Errorf("err = %v, want an upstream 403", err)
+	}
+}
+
+func TestZyteFetcher(t *testing.T) {
+	stubProvider(
+		t, &zyteURL, func(w http.ResponseWriter, r *http.Request) {
+			user, _, _ := r.BasicAuth()
+
+			var payload map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+
+			if user != "key" ||
+				payload["url"] != "https://example.com" ||
+				payload["browserHtml"] != true ||
+				payload["javascript"] != false ||
+				payload["geolocation"] != "US" {
+				t.Errorf("unexpected request %s %v", user, payload)
+			}
+
+			_, _ = w.Write([]byte(`{"url": "https://example.com", "browserHtml": "<p>zyte</p>"}`))
+		},
+	)
+
+	fetcher := ZyteFetcher{
+		APIKey:  "key",
+		Options: map[string]string{"javascript": "false", "geolocation": "US"},
+	}
+	content, err := fetcher.Fetch(context.Background(), "https://example.com")
+	if err != nil || !strings.Contains(string(content), "zyte") {
+		t.Errorf("got %q, %v", content, err)
+	}
+}
@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"fmt"
+	neturl "net/url"
+	"sync"
+	"time"
+)
+
+// ProxyPool rotates fetches across proxies, taking a proxy out of the
+// rotation for Cooldown after FailureLimit consecutive failures.
+type ProxyPool struct {
+	Proxies      []string
+	FailureLimit int
+	Cooldown     time.Duration
+
+	mu       sync.Mutex
+	next     int
+	failures map[string]int
+	benched  map[string]time.Time
+	now      func() time.Time
+}
+
+// NewProxyPool validates proxies, which Chrome only accepts without
+// credentials.
+func NewProxyPool(
+	proxies []string,
+	failureLimit int,
+	cooldown time.Duration,
+) (*ProxyPool, error) {
+	for _, proxy := range proxies {
+		parsed, err := neturl.Parse(proxy)
+		if err != nil || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q", proxy)
+		}
+
+		switch parsed.Scheme {
+		case "http", "https", "socks4", "socks5":
+		default:
+			return nil, fmt.Errorf("invalid proxy %q: unsupported scheme", proxy)
+		}
+
+		if parsed.User != nil {
+			return nil, fmt.Errorf("invalid proxy %q: credentials are not supported", proxy)
+		}
+	}
+
+	return &ProxyPool{
+		Proxies:      proxies,
+		FailureLimit: failureLimit,
+		Cooldown:     cooldown,
+	}, nil
+}
+
+func (p *ProxyPool) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+
+	return time.Now()
+}
+
+// Next returns the next proxy of the rotation. When every proxy is out of
+// the rotation, the one due back first is used rather than none.
+func (p *ProxyPool) Next() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock()
+	var soonest string
+
+	for range p.Proxies {
+		proxy := p.Proxies[p.next%len(p.Proxies)]
+		p.next++
+
+		until, ok := p.benched[proxy]
+		if !ok || !now.Before(until) {
+			return proxy
+		}
+
+		if soonest == "" || until.Before(p.benched[soonest]) {
+			soonest = proxy
+		}
+	}
+
+	return soonest
+}
+
+// Report records the outcome of a fetch through proxy. Errors caused by the
+// Browserless account or the caller are not held against the proxy.
+func (p *ProxyPool) Report(proxy string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failures == nil {
+		p.failures = map[string]int{}
+		p.benched = map[string]time.Time{}
+	}
+
+	if !isUpstreamFailure(err) {
+		if err == nil {
+			delete(p.failures, proxy)
+			delete(p.benched, proxy)
+		}
+		return
+	}
+
+	p.failures[proxy]++
+	if p.failures[proxy] >= p.FailureLimit {
+		p.failures[proxy] = 0
+		p.benched[proxy] = p.clock().Add(p.Cooldown)
+	}
+}
+
+// proxyHost returns the host of proxy, for logs and traces.
+func proxyHost(proxy string) string {
+	parsed, err := neturl.Parse(proxy)
+	if err != nil {
+		return ""
+	}
+
+	return parsed.Host
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestProxyPoolRotation(t *testing.T) {
+	pool, err := NewProxyPool(
+		[]string{"http://a:8080", "socks5://b:1080"},
+		2,
+		time.Minute,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+
+	if first, second := pool.Next(), pool.Next(); first != "http://a:8080" || second != "socks5://b:1080" {
+		t.Fatalf("unexpected rotation %s, %s", first, second)
+	}
+
+	pool.Report("http://a:8080", errors.New("net::ERR_PROXY_CONNECTION_FAILED"))
+	if got := pool.Next(); got != "http://a:8080" {
+		t.Errorf("a proxy under the failure limit should stay in rotation, got %s", got)
+	}
+
+	pool.Report("http://a:8080", errors.New("net::ERR_PROXY_CONNECTION_FAILED"))
+	for i := 0; i < 3; i++ {
+		if got := pool.Next(); got != "socks5://b:1080" {
+			t.Errorf("a benched proxy was returned: %s", got)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	if first, second := pool.Next(), pool.Next(); first != "http://a:8080" && second != "http://a:8080" {
+		t.Errorf("the proxy should be back after the cooldown, got %s, %s", first, second)
+	}
+}
+
+func TestProxyPoolAllBenched(t *testing.T) {
+	pool, _ := NewProxyPool([]string{"http://a:8080", "http://b:8080"}, 1, time.Minute)
+
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+
+	pool.Report("http://b:8080", errors.New("timeout"))
+	now = now.Add(time.Second)
+	pool.Report("http://a:8080", errors.New("timeout"))
+
+	if got := pool.Next(); got != "http://b:8080" {
+		t.Errorf("expected the proxy due back first, got %s", got)
+	}
+}
+
+func TestProxyPoolIgnoresAccountErrors(t *testing.T) {
+	pool, _ := NewProxyPool([]string{"http://a:8080"}, 1, time.Minute)
+
+	pool.Report("http://a:8080", &UpstreamError{StatusCode: http.StatusTooManyRequests})
+	pool.Report("http://a:8080", context.Canceled)
+
+	if _, benched := pool.benched["http://a:8080"]; benched {
+		t.Error("account and caller errors should not bench a proxy")
+	}
+}
+
+func TestNewProxyPoolValidation(t *testing.T) {
+	for _, proxy := range []string{"ftp://a:21", "http://user:pass@a:8080", "a:8080"} {
+		if _, err := NewProxyPool([]string{proxy}, 1, time.Minute); err == nil {
+			t.Errorf("expected %q to be rejected", proxy)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBuckets bounds the number of tracked clients before full buckets are
+// pruned.
+const maxBuckets = 10_000
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter is a token bucket limiter keyed by client. Each client may
+// burst up to Burst requests, refilled at Rate requests per second.
+type RateLimiter struct {
+	Rate  float64
+	Burst int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+// ParseRate parses a "<requests>/<duration>" limit such as "30/1m" into a
+// limiter allowing bursts of requests, refilled over duration.
+func ParseRate(rate string) (*RateLimiter, error) {
+	rawRequests, rawPeriod, ok := strings.Cut(rate, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid rate %q: expected <requests>/<duration>", rate)
+	}
+
+	requests, err := strconv.Atoi(rawRequests)
+	if err != nil || requests <= 0 {
+		return nil, fmt.Errorf("invalid rate %q: bad request count", rate)
+	}
+
+	period, err := time.ParseDuration(rawPeriod)
+	if err != nil || period <= 0 {
+		return nil, fmt.Errorf("invalid rate %q: bad duration", rate)
+	}
+
+	return &RateLimiter{
+		Rate:  float64(requests) / period.Seconds(),
+		Burst: requests,
+	}, nil
+}
+
+func (l *RateLimiter) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+
+	return time.Now()
+}
+
+// Allow takes a token from the bucket of key, returning how long the client
+// has to wait when the bucket is empty.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock()
+
+	if l.buckets == nil {
+		l.buckets = map[string]*tokenBucket{}
+	}
+	if len(l.buckets) >= maxBuckets {
+		l.prune(now)
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(l.Burst), last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(
+		float64(l.Burst),
+		bucket.tokens+now.Sub(bucket.last).Seconds()*l.Rate,
+	)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.Rate * float64(time.Second))
+		return false, wait
+	}
+
+	bucket.tokens--
+	return true, 0
+}
+
+// prune drops the buckets that have refilled completely, since they are
+// equivalent to a new bucket.
+func (l *RateLimiter) prune(now time.Time) {
+	for key, bucket := range l.buckets {
+		refilled := bucket.tokens + now.Sub(bucket.last).Seconds()*l.Rate
+		if refilled >= float64(l.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientIP returns the address of the caller. Behind a trusted proxy it is
+// the address the proxy saw: X-Real-Ip, which Vercel overwrites, else the
+// last X-Forwarded-For entry, which the proxy appends; the earlier entries
+// are sent by the client. Without one, the headers are the client's own
+// and only the connection's address can be trusted.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-Ip")); ip != "" {
+			return ip
+		}
+
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			last := forwarded[len(forwarded)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func rejectRateLimited(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	WriteProblem(
+		w,
+		http.StatusTooManyRequests,
+		"rate_limited",
+		"rate limit exceeded",
+	)
+}
+
+// RateLimit limits requests per client IP with limiter, which may be nil,
+// identifying clients by the forwarding headers when trustProxy is set.
+// API keys are limited by APIKeyAuth, once they are known to be valid.
+func RateLimit(
+	limiter *RateLimiter,
+	trustProxy bool,
+	handler http.HandlerFunc,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if limiter != nil {
+			if ok, wait := limiter.Allow(clientIP(r, trustProxy)); !ok {
+				rejectRateLimited(w, wait)
+				return
+			}
+		}
+
+		handler(w, r)
+	}
+}
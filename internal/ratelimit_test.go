@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	limiter, err := ParseRate("30/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limiter.Burst != 30 || limiter.Rate != 0.5 {
+		t.Errorf("unexpected limiter %+v", limiter)
+	}
+
+	for _, rate := range []string{"30", "x/1m", "30/soon", "0/1m"} {
+		if _, err := ParseRate(rate); err == nil {
+			t.Errorf("ParseRate(%q) should fail", rate)
+		}
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limiter := &RateLimiter{Rate: 1, Burst: 2, now: func() time.Time { return now }}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("a"); !ok {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+
+	ok, wait := limiter.Allow("a")
+	if ok || wait != time.Second {
+		t.Errorf("Allow = %v, %v; want false, 1s", ok, wait)
+	}
+
+	if ok, _ := limiter.Allow("b"); !ok {
+		t.Error("other clients should have their own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := limiter.Allow("a"); !ok {
+		t.Error("bucket should refill over time")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	for _, trustProxy := range []bool{true, false} {
+		handler := RateLimit(
+			&RateLimiter{Rate: 0.1, Burst: 1},
+			trustProxy,
+			func(w http.ResponseWriter, r *http.Request) {},
+		)
+
+		spoofed := 0
+		request := func(ip string) *httptest.ResponseRecorder {
+			// the client sends a different address on every request
+			spoofed++
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Forwarded-For", "10.0.0."+strconv.Itoa(spoofed)+", "+ip)
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			return rec
+		}
+
+		if rec := request("1.1.1.1"); rec.Code != http.StatusOK {
+			t.Fatalf("first request: status %d", rec.Code)
+		}
+
+		rec := request("1.1.1.1")
+		if rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("same IP: status %d", rec.Code)
+		}
+		if got := rec.Header().Get("Retry-After"); got != "10" {
+			t.Errorf("Retry-After = %q", got)
+		}
+
+		// without a proxy, the connection is the same whatever the headers
+		want := http.StatusOK
+		if !trustProxy {
+			want = http.StatusTooManyRequests
+		}
+		if rec := request("2.2.2.2"); rec.Code != want {
+			t.Errorf("trustProxy %v, another IP: status %d, want %d", trustProxy, rec.Code, want)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		headers map[string][]string
+		want    string
+	}{
+		{map[string][]string{}, "192.0.2.1"},
+		{map[string][]string{"X-Forwarded-For": {"10.0.0.1, 1.1.1.1"}}, "1.1.1.1"},
+		{map[string][]string{"X-Forwarded-For": {"10.0.0.1", "1.1.1.1"}}, "1.1.1.1"},
+		{map[string][]string{"X-Forwarded-For": {"10.0.0.1"}, "X-Real-Ip": {"2.2.2.2"}}, "2.2.2.2"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for name, values := range test.headers {
+			req.Header[name] = values
+		}
+
+		if got := clientIP(req, true); got != test.want {
+			t.Errorf("clientIP(%v) = %s, want %s", test.headers, got, test.want)
+		}
+		if got := clientIP(req, false); got != "192.0.2.1" {
+			t.Errorf("clientIP(%v) without a proxy = %s, want the remote address", test.headers, got)
+		}
+	}
+}
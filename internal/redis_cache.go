@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache is a Cache shared by every instance connected to the same
+// Redis server. Keys are namespaced with Prefix.
+type RedisCache struct {
+	Client *redis.Client
+	Prefix string
+}
+
+// NewRedisCache connects to the server at a redis:// or rediss:// URL.
+func NewRedisCache(redisURL string, prefix string) (*RedisCache, error) {
+	options, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisCache{Client: redis.NewClient(options), Prefix: prefix}, nil
+}
+
+// Ping checks that the Redis server is reachable.
+func (c *RedisCache) Ping(ctx context.Context) error {
+	return c.Client.Ping(ctx).Err()
+}
+
+// Keys lists the cached keys matching the glob pattern.
+func (c *RedisCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+
+	iter := c.Client.Scan(ctx, 0, c.Prefix+pattern, 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, strings.TrimPrefix(iter.Val(), c.Prefix))
+	}
+
+	return keys, iter.Err()
+}
+
+// Delete removes keys from the cache, returning how many existed.
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.Prefix + key
+	}
+
+	deleted, err := c.Client.Del(ctx, prefixed...).Result()
+	return int(deleted), err
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) (CacheEntry, bool, error) {
+	raw, err := c.Client.Get(ctx, c.Prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return CacheEntry{}, false, nil
+	}
+	if err != nil {
+		return CacheEntry{}, false, err
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return CacheEntry{}, false, err
+	}
+
+	return entry, true, nil
+}
+
+func (c *RedisCache) Set(
+	ctx context.Context,
+	key string,
+	entry CacheEntry,
+	ttl time.Duration,
+) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return c.Client.Set(ctx, c.Prefix+key, raw, ttl).Err()
+}
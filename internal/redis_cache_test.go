@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedisCache(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	cache, err := NewRedisCache("redis://"+server.Addr(), "test:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, ok, err := cache.Get(ctx, "k"); ok || err != nil {
+		t.Fatalf("empty cache: %v %v", ok, err)
+	}
+
+	stored := CacheEntry{
+		Value:    []byte(`{"name":"bundle"}`),
+		StoredAt: time.Unix(1_700_000_000, 0).UTC(),
+	}
+	if err := cache.Set(ctx, "k", stored, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if !server.Exists("test:k") {
+		t.Error("expected the key to be prefixed")
+	}
+
+	entry, ok, err := cache.Get(ctx, "k")
+	if err != nil || !ok {
+		t.Fatalf("cached entry: %v %v", ok, err)
+	}
+	if string(entry.Value) != string(stored.Value) || !entry.StoredAt.Equal(stored.StoredAt) {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	_ = cache.Set(ctx, "other", stored, time.Minute)
+	keys, err := cache.Keys(ctx, "k*")
+	if err != nil || len(keys) != 1 || keys[0] != "k" {
+		t.Errorf("Keys = %v, %v", keys, err)
+	}
+
+	if deleted, err := cache.Delete(ctx, "other", "missing"); err != nil || deleted != 1 {
+		t.Errorf("Delete = %d, %v", deleted, err)
+	}
+
+	server.FastForward(2 * time.Minute)
+	if _, ok, _ := cache.Get(ctx, "k"); ok {
+		t.Error("entries should expire after their ttl")
+	}
+}
@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+)
+
+const (
+	// maxRobotsSize caps the robots.txt files read, as crawlers
+	// conventionally ignore what follows the first 500 KiB.
+	maxRobotsSize = 500 << 10
+	// robotsUserAgent is the agent robots.txt groups are matched against.
+	robotsUserAgent = "humblebundle-scraper"
+	// robotsTTL is how long a robots.txt is kept before it is fetched again.
+	robotsTTL = 24 * time.Hour
+)
+
+// ErrDisallowed is returned for pages robots.txt disallows scraping.
+var ErrDisallowed = errors.New("robots.txt disallows scraping the url")
+
+type robotsHost struct {
+	robots    *robotstxt.RobotsData
+	delay     time.Duration
+	fetchedAt time.Time
+	// next is the earliest time the host may be fetched again under its
+	// crawl delay.
+	next time.Time
+}
+
+// RobotsPolicy honors the robots.txt of each host: disallowed pages are not
+// fetched and fetches of a host are spaced by its crawl delay. Only the
+// scraped sites are fetched, which bounds the hosts it keeps.
+type RobotsPolicy struct {
+	mu    sync.Mutex
+	hosts map[string]*robotsHost
+	now   func() time.Time
+}
+
+func (p *RobotsPolicy) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+
+	return time.Now()
+}
+
+// Wait returns ErrDisallowed when robots.txt disallows url, else waits for
+// the crawl delay of its host. URLs of other sites are refused with
+// ErrUnsupportedSite before their robots.txt is requested.
+func (p *RobotsPolicy) Wait(ctx context.Context, url string) error {
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return ErrInvalidURL
+	}
+
+	if sourceOf(url) == "" {
+		return ErrUnsupportedSite
+	}
+
+	host, err := p.host(ctx, parsed)
+	if err != nil {
+		return err
+	}
+
+	if !host.robots.TestAgent(parsed.RequestURI(), robotsUserAgent) {
+		return ErrDisallowed
+	}
+
+	p.mu.Lock()
+	now := p.clock()
+	wait := host.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	host.next = now.Add(wait + host.delay)
+	p.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// host returns the robots.txt rules of the host of url, fetching them
+// when they are missing or expired.
+func (p *RobotsPolicy) host(ctx context.Context, url *neturl.URL) (*robotsHost, error) {
+	key := url.Scheme + "://" + url.Host
+
+	p.mu.Lock()
+	host, ok := p.hosts[key]
+	p.mu.Unlock()
+
+	if ok && p.clock().Sub(host.fetchedAt) < robotsTTL {
+		return host, nil
+	}
+
+	robots, err := fetchRobots(ctx, key+"/robots.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.hosts == nil {
+		p.hosts = map[string]*robotsHost{}
+	}
+
+	fetched := &robotsHost{
+		robots:    robots,
+		fetchedAt: p.clock(),
+	}
+	if group := robots.FindGroup(robotsUserAgent); group != nil {
+		fetched.delay = group.CrawlDelay
+	}
+	if ok {
+		fetched.next = host.next
+	}
+	p.hosts[key] = fetched
+
+	return fetched, nil
+}
+
+// fetchRobots downloads a robots.txt. A missing file allows everything and
+// a server error disallows everything, as robots.txt parsers conventionally
+// do.
+func fetchRobots(ctx context.Context, url string) (*robotstxt.RobotsData, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := publicClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching robots.txt: %w", err)
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return robotstxt.FromStatusAndBytes(resp.StatusCode, body)
+}
+
+// RobotsFetcher fetches only the pages Policy allows, at the pace it
+// allows.
+type RobotsFetcher struct {
+	Fetcher
+	Policy *RobotsPolicy
+}
+
+func (f RobotsFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	if err := f.Policy.Wait(ctx, url); err != nil {
+		return nil, err
+	}
+
+	return f.Fetcher.Fetch(ctx, url)
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRobotsFetcher(t *testing.T) {
+	robotsRequests := 0
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				robotsRequests++
+				_, _ = w.Write(
+					[]byte(
+						"User-agent: *\nDisallow: /\n\n" +
+							"User-agent: humblebundle-scraper\nDisallow: /private\nCrawl-delay: 1\n",
+					),
+				)
+			},
+		),
+	)
+	defer server.Close()
+	routePublicClient(t, server)
+
+	site := "http://www.humblebundle.com"
+	policy := &RobotsPolicy{}
+	fetcher := RobotsFetcher{
+		Fetcher: stubFetcher{site + "/books": "<p>books</p>"},
+		Policy:  policy,
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), site+"/private/page"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("err = %v, want ErrDisallowed", err)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), site+"/books"); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if _, err := fetcher.Fetch(context.Background(), site+"/books"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("the crawl delay was not honored, waited %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := fetcher.Fetch(ctx, site+"/books"); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want the context error while waiting", err)
+	}
+
+	if robotsRequests != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1", robotsRequests)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/books"); !errors.Is(err, ErrUnsupportedSite) {
+		t.Errorf("err = %v, want ErrUnsupportedSite", err)
+	}
+	if robotsRequests != 1 || len(policy.hosts) != 1 {
+		t.Error("the robots.txt of other sites should not be requested")
+	}
+}
+
+func TestRobotsServerError(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		),
+	)
+	defer server.Close()
+	routePublicClient(t, server)
+
+	err := (&RobotsPolicy{}).Wait(context.Background(), "http://thewoksoflife.com/books")
+	if !errors.Is(err, ErrDisallowed) {
+		t.Errorf("err = %v, want ErrDisallowed", err)
+	}
+}
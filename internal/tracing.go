@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "humblebundle-scraper"
+
+var tracer = otel.Tracer(serviceName)
+
+var (
+	tracingOnce    sync.Once
+	tracerProvider *sdktrace.TracerProvider
+)
+
+// setupTracing installs the W3C trace context propagator and, when an OTLP
+// endpoint is configured through the standard OTEL_EXPORTER_OTLP_* variables,
+// a tracer provider exporting spans to it. Without an endpoint spans are
+// no-ops.
+func setupTracing() {
+	otel.SetTextMapPropagator(
+		propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	)
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" &&
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return
+	}
+
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		slog.Default().Warn("tracing disabled", "error", err)
+		return
+	}
+
+	tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(
+			resource.NewSchemaless(semconv.ServiceNameKey.String(serviceName)),
+		),
+	)
+	otel.SetTracerProvider(tracerProvider)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Traced wraps handler in a server span, continuing the trace propagated by
+// the caller. The URL query is deliberately left out of the span attributes
+// since it carries the Browserless token.
+func Traced(name string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tracingOnce.Do(setupTracing)
+
+		ctx := otel.GetTextMapPropagator().Extract(
+			r.Context(),
+			propagation.HeaderCarrier(r.Header),
+		)
+		ctx, span := tracer.Start(
+			ctx,
+			name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.method", r.Method),
+				attribute.String("http.route", r.URL.Path),
+			),
+		)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+		span.End()
+
+		// serverless instances may be frozen right after responding, so
+		// spans are flushed before returning instead of waiting for the batch
+		if tracerProvider != nil {
+			flushCtx, cancel := context.WithTimeout(
+				context.Background(),
+				2*time.Second,
+			)
+			_ = tracerProvider.ForceFlush(flushCtx)
+			cancel()
+		}
+	}
+}
+
+// traceField runs extract inside a span named after the field it scrapes.
+func traceField(ctx context.Context, field string, extract func()) {
+	_, span := tracer.Start(ctx, "extract "+field)
+	defer span.End()
+
+	extract()
+}
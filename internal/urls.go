@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// errPrivateAddress is returned for connections to addresses that are not
+// on the public internet.
+var errPrivateAddress = errors.New("refusing to connect to a non-public address")
+
+// sharedAddressSpace is the carrier-grade NAT range, not covered by
+// net.IP.IsPrivate.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicClient requests the scraped sites directly. It only follows
+// redirects to the scraped sites and refuses to connect to loopback,
+// private and link-local addresses, whatever the hosts resolve to.
+var publicClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Control: dialPublicOnly}).DialContext,
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: http.DefaultTransport.(*http.Transport).TLSHandshakeTimeout,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if sourceOf(req.URL.String()) == "" {
+			return ErrUnsupportedSite
+		}
+
+		return nil
+	},
+}
+
+// dialPublicOnly rejects connections to resolved addresses that are not on
+// the public internet.
+func dialPublicOnly(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !isPublicAddr(ip.Unmap()) {
+		return fmt.Errorf("%s: %w", host, errPrivateAddress)
+	}
+
+	return nil
+}
+
+func isPublicAddr(ip netip.Addr) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// sources names the sites the scraper fetches from, by host.
+var sources = map[string]string{
+	"humblebundle.com":      "humblebundle",
+	"www.humblebundle.com":  "humblebundle",
+	"thewoksoflife.com":     "woksoflife",
+	"www.thewoksoflife.com": "woksoflife",
+	"web.archive.org":       "wayback",
+}
+
+// sourceOf returns the name of the site of rawURL, or "" for the sites the
+// scraper does not know.
+func sourceOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return sources[strings.ToLower(u.Hostname())]
+}
+
+// NormalizeURL returns the form of rawURL used to identify a page: the
+// scheme and host lowercased, without fragment or trailing slash.
+func NormalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+
+	return u.String()
+}
+
+// ValidateURL checks that rawURL can be handed to a fetcher: an absolute
+// http(s) URL of one of the scraped sites, so the scraping services paid
+// for are not used to fetch arbitrary pages. Wayback Machine URLs, which
+// archive any page, are only built by WaybackFallback.
+func ValidateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+
+	if source := sourceOf(rawURL); source == "" || source == "wayback" {
+		return ErrUnsupportedSite
+	}
+
+	return nil
+}
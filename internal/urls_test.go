@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateURL(t *testing.T) {
+	valid := []string{"https://www.humblebundle.com/books/x", "http://thewoksoflife.com/x"}
+	invalid := []string{"", "www.humblebundle.com/books", "ftp://example.com", "https://"}
+
+	for _, rawURL := range valid {
+		if err := ValidateURL(rawURL); err != nil {
+			t.Errorf("ValidateURL(%q) = %v", rawURL, err)
+		}
+	}
+	for _, rawURL := range invalid {
+		if err := ValidateURL(rawURL); err == nil {
+			t.Errorf("ValidateURL(%q) should fail", rawURL)
+		}
+	}
+
+	unsupported := []string{
+		"http://169.254.169.254/latest",
+		"https://web.archive.org/web/2024/http://169.254.169.254/latest",
+		"https://web.archive.org/save/https://example.com",
+	}
+	for _, rawURL := range unsupported {
+		if err := ValidateURL(rawURL); !errors.Is(err, ErrUnsupportedSite) {
+			t.Errorf("ValidateURL(%q) = %v, want ErrUnsupportedSite", rawURL, err)
+		}
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]string{
+		"HTTPS://WWW.HumbleBundle.com/books/x/#tiers": "https://www.humblebundle.com/books/x",
+		"https://thewoksoflife.com/kung-pao-chicken/": "https://thewoksoflife.com/kung-pao-chicken",
+		"https://example.com/page?id=1":               "https://example.com/page?id=1",
+	}
+
+	for rawURL, expected := range tests {
+		if got := NormalizeURL(rawURL); got != expected {
+			t.Errorf("NormalizeURL(%q) = %q, want %q", rawURL, got, expected)
+		}
+	}
+}
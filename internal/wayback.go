@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"time"
+)
+
+// waybackAvailabilityURL is the Wayback Machine API looking up the latest
+// snapshot of a page.
+var waybackAvailabilityURL = "https://archive.org/wayback/available"
+
+// waybackTimestamp is the layout of Wayback Machine snapshot timestamps.
+const waybackTimestamp = "20060102150405"
+
+// Snapshot is a Wayback Machine capture of a page.
+type Snapshot struct {
+	URL       string    `json:"url" yaml:"url" xml:"url"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp" xml:"timestamp"`
+}
+
+// LatestSnapshot returns the latest successful capture of url, or
+// ErrNotFound when the page was never archived.
+func LatestSnapshot(ctx context.Context, url string) (Snapshot, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		waybackAvailabilityURL+"?url="+neturl.QueryEscape(url),
+		nil,
+	)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return Snapshot{}, &UpstreamError{StatusCode: resp.StatusCode, Body: "wayback machine"}
+	}
+
+	var availability struct {
+		ArchivedSnapshots struct {
+			Closest struct {
+				Available bool   `json:"available"`
+				URL       string `json:"url"`
+				Timestamp string `json:"timestamp"`
+				Status    string `json:"status"`
+			} `json:"closest"`
+		} `json:"archived_snapshots"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&availability); err != nil {
+		return Snapshot{}, fmt.Errorf("wayback machine: %w", err)
+	}
+
+	closest := availability.ArchivedSnapshots.Closest
+	if !closest.Available || closest.Status != "200" {
+		return Snapshot{}, ErrNotFound
+	}
+
+	timestamp, err := time.Parse(waybackTimestamp, closest.Timestamp)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("wayback machine: %w", err)
+	}
+
+	return Snapshot{URL: closest.URL, Timestamp: timestamp}, nil
+}
+
+// pageGone reports whether err means the page is not there anymore: it has
+// none of the content looked for, or the site answers 404 or 410.
+func pageGone(err error) bool {
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		return upstreamErr.StatusCode == http.StatusNotFound ||
+			upstreamErr.StatusCode == http.StatusGone
+	}
+
+	return errors.Is(err, ErrNotFound)
+}
+
+// WaybackFallback scrapes url and, when the page is gone or has none of the
+// content looked for, its latest Wayback Machine snapshot instead. The
+// snapshot is nil when the live page was scraped.
+func WaybackFallback[T any](
+	ctx context.Context,
+	fetcher Fetcher,
+	url string,
+	scrape func(ctx context.Context, fetcher Fetcher, url string) (T, error),
+) (T, *Snapshot, error) {
+	result, err := scrape(ctx, fetcher, url)
+	if !pageGone(err) {
+		return result, nil, err
+	}
+
+	snapshot, snapshotErr := LatestSnapshot(ctx, url)
+	if snapshotErr != nil {
+		LoggerFrom(ctx).Debug(
+			"no wayback machine snapshot",
+			"url", url,
+			"error", snapshotErr,
+		)
+		return result, nil, err
+	}
+
+	result, err = scrape(ctx, fetcher, snapshot.URL)
+	if err != nil {
+		return result, nil, err
+	}
+
+	return result, &snapshot, nil
+}
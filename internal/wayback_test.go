@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func stubWayback(t *testing.T, body string) {
+	t.Helper()
+
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("url") != "https://www.humblebundle.com/books/expired" {
+					t.Errorf("unexpected lookup %s", r.URL)
+				}
+
+				_, _ = w.Write([]byte(body))
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+
+	previous := waybackAvailabilityURL
+	waybackAvailabilityURL = server.URL
+	t.Cleanup(func() { waybackAvailabilityURL = previous })
+}
+
+func TestWaybackFallback(t *testing.T) {
+	snapshotURL := "http://web.archive.org/web/20230102030405/https://www.humblebundle.com/books/expired"
+	stubWayback(
+		t,
+		`{"archived_snapshots": {"closest": {"available": true, "status": "200", "timestamp": "20230102030405", "url": "`+snapshotURL+`"}}}`,
+	)
+
+	fetcher := stubFetcher{
+		"https://www.humblebundle.com/books/expired": "<html></html>",
+		snapshotURL: `<img class="bundle-logo" alt="Expired"/><span class="item-title">Book</span>`,
+	}
+
+	bundle, snapshot, err := WaybackFallback(
+		context.Background(),
+		fetcher,
+		"https://www.humblebundle.com/books/expired",
+		GetBundleData,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bundle.Name != "Expired" {
+		t.Errorf("unexpected bundle %+v", bundle)
+	}
+	if snapshot == nil ||
+		snapshot.URL != snapshotURL ||
+		!snapshot.Timestamp.Equal(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+}
+
+// goneFetcher answers 404 for the pages it does not have.
+type goneFetcher stubFetcher
+
+func (f goneFetcher) Fetch(_ context.Context, url string) ([]byte, error) {
+	content, ok := f[url]
+	if !ok {
+		return nil, &UpstreamError{StatusCode: http.StatusNotFound}
+	}
+
+	return []byte(content), nil
+}
+
+func TestWaybackFallbackOnUpstream404(t *testing.T) {
+	snapshotURL := "http://web.archive.org/web/20230102030405/https://www.humblebundle.com/books/expired"
+	stubWayback(
+		t,
+		`{"archived_snapshots": {"closest": {"available": true, "status": "200", "timestamp": "20230102030405", "url": "`+snapshotURL+`"}}}`,
+	)
+
+	bundle, snapshot, err := WaybackFallback(
+		context.Background(),
+		goneFetcher{
+			snapshotURL: `<img class="bundle-logo" alt="Expired"/><span class="item-title">Book</span>`,
+		},
+		"https://www.humblebundle.com/books/expired",
+		GetBundleData,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bundle.Name != "Expired" || snapshot == nil {
+		t.Errorf("unexpected bundle %+v from snapshot %+v", bundle, snapshot)
+	}
+}
+
+func TestWaybackFallbackNotArchived(t *testing.T) {
+	stubWayback(t, `{"archived_snapshots": {}}`)
+
+	_, snapshot, err := WaybackFallback(
+		context.Background(),
+		stubFetcher{"https://www.humblebundle.com/books/expired": "<html></html>"},
+		"https://www.humblebundle.com/books/expired",
+		GetBundleData,
+	)
+	if !errors.Is(err, ErrNotFound) || snapshot != nil {
+		t.Errorf("got %v, %v, want ErrNotFound", snapshot, err)
+	}
+}
@@ -2,7 +2,9 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"strconv"
 	"strings"
 	"time"
@@ -11,12 +13,15 @@ import (
 )
 
 type Recipe struct {
-	Name         string
-	Image        string
-	PrepTime     time.Duration
-	Ingredients  []string
-	Instructions []string
-	Notes        string
+	XMLName      xml.Name      `json:"-" yaml:"-" xml:"recipe"`
+	Name         string        `json:"name" yaml:"name" xml:"name"`
+	Image        string        `json:"image" yaml:"image" xml:"image"`
+	PrepTime     time.Duration `json:"prepTime" yaml:"prepTime" xml:"prepTime"`
+	Ingredients  []string      `json:"ingredients" yaml:"ingredients" xml:"ingredients>ingredient"`
+	Instructions []string      `json:"instructions" yaml:"instructions" xml:"instructions>instruction"`
+	Notes        string        `json:"notes" yaml:"notes" xml:"notes"`
+	// Archived is the snapshot scraped instead of a removed recipe page.
+	Archived *Snapshot `json:"archived,omitempty" yaml:"archived,omitempty" xml:"archived,omitempty"`
 }
 
 func (r Recipe) String() string {
@@ -24,48 +29,84 @@ func (r Recipe) String() string {
 	return string(recipe)
 }
 
-func GetRecipe(browserlessToken, url string) (Recipe, error) {
-	htmlContent, err := GrabContent(browserlessToken, url)
+func GetRecipe(ctx context.Context, fetcher Fetcher, url string) (
+	Recipe,
+	error,
+) {
+	htmlContent, err := fetcher.Fetch(ctx, url)
 	if err != nil {
 		return Recipe{}, err
 	}
 
+	ctx, span := tracer.Start(ctx, "parse")
+	defer span.End()
+
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(htmlContent))
 	if err != nil {
 		return Recipe{}, err
 	}
 
-	name := doc.Find(".wprm-recipe-name").Text()
+	var name string
+	traceField(
+		ctx, "name", func() {
+			name = doc.Find(".wprm-recipe-name").Text()
+		},
+	)
 
-	image, _ := doc.Find(".attachment-post-thumbnail").Attr("data-lazy-src")
+	var image string
+	traceField(
+		ctx, "image", func() {
+			image, _ = doc.Find(".attachment-post-thumbnail").Attr("data-lazy-src")
+		},
+	)
 
 	var prepTime time.Duration
+	traceField(
+		ctx, "prepTime", func() {
+			hours, err := strconv.Atoi(doc.Find(".wprm-recipe-total_time-hours").Text())
+			if err == nil {
+				prepTime += time.Duration(hours) * time.Hour
+			}
 
-	hours, err := strconv.Atoi(doc.Find(".wprm-recipe-total_time-hours").Text())
-	if err == nil {
-		prepTime += time.Duration(hours) * time.Hour
-	}
-
-	minutes, err := strconv.Atoi(doc.Find(".wprm-recipe-total_time-minutes").Text())
-	if err == nil {
-		prepTime += time.Duration(minutes) * time.Minute
-	}
+			minutes, err := strconv.Atoi(doc.Find(".wprm-recipe-total_time-minutes").Text())
+			if err == nil {
+				prepTime += time.Duration(minutes) * time.Minute
+			}
+		},
+	)
 
 	var ingredients []string
-	doc.Find(".wprm-recipe-ingredient").Each(
-		func(_ int, s *goquery.Selection) {
-			ingredients = append(ingredients, strings.TrimLeft(s.Text(), "▢ "))
+	traceField(
+		ctx, "ingredients", func() {
+			doc.Find(".wprm-recipe-ingredient").Each(
+				func(_ int, s *goquery.Selection) {
+					ingredients = append(ingredients, strings.TrimLeft(s.Text(), "▢ "))
+				},
+			)
 		},
 	)
 
 	var instructions []string
-	doc.Find(".wprm-recipe-instruction").Each(
-		func(_ int, s *goquery.Selection) {
-			instructions = append(instructions, s.Text())
+	traceField(
+		ctx, "instructions", func() {
+			doc.Find(".wprm-recipe-instruction").Each(
+				func(_ int, s *goquery.Selection) {
+					instructions = append(instructions, s.Text())
+				},
+			)
 		},
 	)
 
-	notes := doc.Find(".wprm-recipe-notes").Text()
+	var notes string
+	traceField(
+		ctx, "notes", func() {
+			notes = doc.Find(".wprm-recipe-notes").Text()
+		},
+	)
+
+	if name == "" && len(ingredients) == 0 {
+		return Recipe{}, ErrNotFound
+	}
 
 	return Recipe{
 		Name:         name,
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGetRecipe(t *testing.T) {
+	recipe, err := GetRecipe(
+		context.Background(),
+		ReplayFetcher{Dir: "testdata"},
+		"https://thewoksoflife.com/kung-pao-chicken/",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, "woksoflife-kung-pao-chicken", recipe)
+}
+
+func TestGetRecipeNotFound(t *testing.T) {
+	url := "https://thewoksoflife.com/about/"
+	_, err := GetRecipe(
+		context.Background(),
+		stubFetcher{url: "<html><body><p>About us</p></body></html>"},
+		url,
+	)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func BenchmarkGetRecipe(b *testing.B) {
+	url := "https://thewoksoflife.com/kung-pao-chicken/"
+	fetcher := fixtureFetcher(b, url)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := GetRecipe(ctx, fetcher, url); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
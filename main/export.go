@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"humblebundle-scraper/internal"
+)
+
+var bundleReport = template.Must(
+	template.New("bundle").Parse(
+		`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>{{.Bundle.Name}}</title>
+</head>
+<body>
+  <h1><a href="{{.URL}}">{{.Bundle.Name}}</a></h1>
+  <ol>
+{{- range .Bundle.Items}}
+    <li>{{.}}</li>
+{{- end}}
+  </ol>
+</body>
+</html>
+`,
+	),
+)
+
+// reportFormats are the formats bundles can be exported as.
+var reportFormats = []string{"md", "csv", "html"}
+
+// markdownEscaper escapes the characters scraped text could be read as
+// markdown syntax with.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+	"#", `\#`,
+)
+
+// bundleURL accepts a bundle URL or its "<category>/<slug>" path.
+func bundleURL(bundle string) string {
+	if strings.HasPrefix(bundle, "http://") || strings.HasPrefix(bundle, "https://") {
+		return bundle
+	}
+
+	return "https://www.humblebundle.com/" + strings.TrimPrefix(bundle, "/")
+}
+
+// exportBundle writes the items of a bundle as a markdown, CSV or HTML
+// report.
+func exportBundle(
+	ctx context.Context,
+	fetcher internal.Fetcher,
+	args []string,
+	out io.Writer,
+) error {
+	flags := flag.NewFlagSet("bundle export", flag.ContinueOnError)
+	format := flags.String("format", "md", "report format: md, csv or html")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New(usage)
+	}
+
+	// checked before the paid scrape
+	if !slices.Contains(reportFormats, *format) {
+		return fmt.Errorf("unknown report format %q: expected md, csv or html", *format)
+	}
+
+	url := bundleURL(flags.Arg(0))
+	if err := internal.ValidateURL(url); err != nil {
+		return err
+	}
+
+	bundle, err := internal.GetBundleData(ctx, fetcher, url)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "md":
+		_, _ = fmt.Fprintf(out, "# [%s](%s)\n\n", markdownEscaper.Replace(bundle.Name), url)
+		for i, item := range bundle.Items {
+			_, _ = fmt.Fprintf(out, "%d. %s\n", i+1, markdownEscaper.Replace(item))
+		}
+
+		return nil
+	case "csv":
+		writer := csv.NewWriter(out)
+		_ = writer.Write([]string{"bundle", "position", "title"})
+		for i, item := range bundle.Items {
+			_ = writer.Write([]string{bundle.Name, strconv.Itoa(i + 1), item})
+		}
+
+		writer.Flush()
+		return writer.Error()
+	default:
+		return bundleReport.Execute(
+			out,
+			struct {
+				URL    string
+				Bundle internal.Bundle
+			}{url, bundle},
+		)
+	}
+}
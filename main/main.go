@@ -1,4 +1,284 @@
+// Command main manages the shared result cache of a deployment, watches
+// for new bundles and exports bundle reports:
+//
+//	go run ./main [flags] cache ls [pattern]
+//	go run ./main [flags] cache purge <pattern>...
+//	go run ./main [flags] cache stats
+//	go run ./main [flags] monitor
+//	go run ./main [flags] bundle export [-format md|csv|html] <url|category/slug>
+//
+// Flags and environment variables are those of the config package; the
+// cache must be stored in Redis (REDIS_URL) to be reachable from here.
+// Keys are "bundle:<url>", "bundles" and "recipe:<url>", and
+// patterns are Redis globs.
+//
+// The monitor polls the bundles on sale with the BROWSERLESS_TOKEN and
+// sends the new ones, and its failures, to the notification channels
+// configured for those events (NOTIFY_EVENTS), until it is interrupted.
+// Bundle reports list the items of a bundle, scraped with the
+// BROWSERLESS_TOKEN too.
 package main
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"humblebundle-scraper/internal"
+	"humblebundle-scraper/internal/config"
+)
+
+const usage = `usage: main [flags] cache ls [pattern]
+       main [flags] cache purge <pattern>...
+       main [flags] cache stats
+       main [flags] monitor
+       main [flags] bundle export [-format md|csv|html] <url|category/slug>`
+
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	cfg, args, err := config.LoadArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	app, err := internal.NewApp(cfg)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "cache":
+		return runCache(ctx, app, args[1:], out)
+	case "monitor":
+		return runMonitor(ctx, app)
+	case "bundle":
+		if len(args) < 2 || args[1] != "export" {
+			return errors.New(usage)
+		}
+		if !app.CanFetch(app.Config.BrowserlessToken) {
+			return errors.New("bundles are scraped with BROWSERLESS_TOKEN, which is not set")
+		}
+
+		return exportBundle(ctx, app.Fetcher(app.Config.BrowserlessToken), args[2:], out)
+	}
+
+	return errors.New(usage)
+}
+
+func runMonitor(ctx context.Context, app *internal.App) error {
+	if !app.CanFetch(app.Config.BrowserlessToken) {
+		return errors.New("the monitor scrapes with BROWSERLESS_TOKEN, which is not set")
+	}
+
+	notifiers := app.Notifiers()
+	if len(notifiers) == 0 {
+		return errors.New("no notification channel is configured")
+	}
+
+	monitor := &internal.Monitor{
+		Fetcher:    app.Fetcher(app.Config.BrowserlessToken),
+		Notifier:   notifiers,
+		Categories: app.Config.Monitor.Categories,
+		StateFile:  app.Config.Monitor.StateFile,
+	}
+
+	err := monitor.Run(ctx, app.Config.Monitor.Interval)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	return err
+}
+
+func runCache(
+	ctx context.Context,
+	app *internal.App,
+	args []string,
+	out io.Writer,
+) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	if app.Cache == nil {
+		return errors.New("caching is disabled (CACHE_TTL=0)")
+	}
+
+	store, ok := app.Cache.Store.(internal.CacheAdmin)
+	if !ok {
+		return errors.New("the cache is kept in each function's memory; set REDIS_URL to manage a shared cache")
+	}
+
+	switch args[0] {
+	case "ls":
+		pattern := "*"
+		if len(args) > 1 {
+			pattern = args[1]
+		}
+
+		return list(ctx, app.Cache, store, pattern, out)
+	case "purge":
+		if len(args) < 2 {
+			return errors.New(usage)
+		}
+
+		return purge(ctx, store, args[1:], out)
+	case "stats":
+		return stats(ctx, app.Cache, store, out)
+	}
+
+	return errors.New(usage)
+}
+
+// entryState classifies an entry by age as the handlers would serve it.
+func entryState(cache *internal.ResultCache, storedAt time.Time) string {
+	age := time.Since(storedAt)
+
+	switch {
+	case age < cache.TTL:
+		return "fresh"
+	case age < cache.TTL+cache.StaleTTL:
+		return "stale"
+	}
+
+	return "expired"
+}
+
+func list(
+	ctx context.Context,
+	cache *internal.ResultCache,
+	store internal.CacheAdmin,
+	pattern string,
+	out io.Writer,
+) error {
+	keys, err := store.Keys(ctx, pattern)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "KEY\tSTATE\tAGE\tBYTES")
+
+	for _, key := range keys {
+		entry, ok, err := store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%d\n",
+			key,
+			entryState(cache, entry.StoredAt),
+			time.Since(entry.StoredAt).Round(time.Second),
+			len(entry.Value),
+		)
+	}
+
+	return writer.Flush()
+}
+
+func purge(
+	ctx context.Context,
+	store internal.CacheAdmin,
+	patterns []string,
+	out io.Writer,
+) error {
+	purged := 0
+
+	for _, pattern := range patterns {
+		keys, err := store.Keys(ctx, pattern)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := store.Delete(ctx, keys...)
+		if err != nil {
+			return err
+		}
+
+		purged += deleted
+	}
+
+	_, _ = fmt.Fprintf(out, "purged %d entries\n", purged)
+	return nil
+}
+
+func stats(
+	ctx context.Context,
+	cache *internal.ResultCache,
+	store internal.CacheAdmin,
+	out io.Writer,
+) error {
+	keys, err := store.Keys(ctx, "*")
+	if err != nil {
+		return err
+	}
+
+	states := map[string]int{}
+	var entries, size int
+	var oldest, newest time.Time
+
+	for _, key := range keys {
+		entry, ok, err := store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		entries++
+		size += len(entry.Value)
+		states[entryState(cache, entry.StoredAt)]++
+
+		if oldest.IsZero() || entry.StoredAt.Before(oldest) {
+			oldest = entry.StoredAt
+		}
+		if entry.StoredAt.After(newest) {
+			newest = entry.StoredAt
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		out,
+		"entries: %d (%d fresh, %d stale)\nbytes: %d\n",
+		entries,
+		states["fresh"],
+		states["stale"],
+		size,
+	)
+
+	if entries > 0 {
+		_, _ = fmt.Fprintf(
+			out,
+			"oldest: %s\nnewest: %s\n",
+			oldest.Format(time.RFC3339),
+			newest.Format(time.RFC3339),
+		)
+	}
+
+	return nil
 }
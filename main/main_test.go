@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"humblebundle-scraper/internal"
+)
+
+func TestCacheCommands(t *testing.T) {
+	server := miniredis.RunT(t)
+	t.Setenv("REDIS_URL", "redis://"+server.Addr())
+
+	store, err := internal.NewRedisCache("redis://"+server.Addr(), "humblebundle-scraper:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	entries := map[string]time.Duration{
+		"bundle:https://www.humblebundle.com/books/a": time.Minute,
+		"bundle:https://www.humblebundle.com/books/b": 2 * time.Hour,
+		"recipe:https://thewoksoflife.com/c":          time.Minute,
+	}
+	for key, age := range entries {
+		entry := internal.CacheEntry{Value: []byte(`{}`), StoredAt: time.Now().Add(-age)}
+		if err := store.Set(ctx, key, entry, time.Hour*48); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the budgets kept next to the cache are not cached results
+	budget := &internal.BrowserlessBudget{MonthlyUnits: 1, Redis: store.Client}
+	budget.Charge(ctx, "token", 1)
+
+	command := func(args ...string) string {
+		t.Helper()
+
+		var out bytes.Buffer
+		if err := run(ctx, args, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		return out.String()
+	}
+
+	listing := command("cache", "ls", "bundle:*")
+	if !strings.Contains(listing, "books/a  fresh") ||
+		!strings.Contains(listing, "books/b  stale") ||
+		strings.Contains(listing, "recipe:") {
+		t.Errorf("unexpected listing:\n%s", listing)
+	}
+
+	if got := command("cache", "stats"); !strings.HasPrefix(got, "entries: 3 (2 fresh, 1 stale)\nbytes: 6\n") {
+		t.Errorf("unexpected stats:\n%s", got)
+	}
+
+	if got := command("cache", "purge", "bundle:*"); got != "purged 2 entries\n" {
+		t.Errorf("unexpected purge output %q", got)
+	}
+
+	if keys, _ := store.Keys(ctx, "*"); len(keys) != 1 {
+		t.Errorf("unexpected keys after purge %v", keys)
+	}
+
+	if got := command("cache", "ls"); strings.Contains(got, "budget") {
+		t.Errorf("the budgets should not be listed:\n%s", got)
+	}
+
+	if got := command("cache", "purge", "*"); got != "purged 1 entries\n" {
+		t.Errorf("unexpected purge output %q", got)
+	}
+	if !budget.Exceeded(ctx, "token") {
+		t.Error("purging the cache should keep the budgets")
+	}
+
+	if err := run(ctx, []string{"cache"}, &bytes.Buffer{}); err == nil {
+		t.Error("expected a usage error")
+	}
+}
+
+func TestCacheCommandsNeedRedis(t *testing.T) {
+	t.Setenv("REDIS_URL", "")
+
+	if err := run(context.Background(), []string{"cache", "ls"}, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error for an in-memory cache")
+	}
+}
+
+func TestExportBundle(t *testing.T) {
+	fetcher := internal.ReplayFetcher{Dir: "../internal/testdata"}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"md", "# [Humble Book Bundle: Fantasy Worlds](https://www.humblebundle.com/books/fantasy-worlds-books)\n\n1. The Name of the Wind\n"},
+		{"csv", "bundle,position,title\nHumble Book Bundle: Fantasy Worlds,1,The Name of the Wind\n"},
+		{"html", "<li>The Name of the Wind</li>"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := exportBundle(
+			context.Background(),
+			fetcher,
+			[]string{"-format", test.format, "books/fantasy-worlds-books"},
+			&out,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(out.String(), test.want) {
+			t.Errorf("%s report missing %q:\n%s", test.format, test.want, out.String())
+		}
+	}
+
+	// the format is checked before scraping
+	err := exportBundle(
+		context.Background(),
+		internal.ReplayFetcher{Dir: t.TempDir()},
+		[]string{"-format", "pdf", "books/fantasy-worlds-books"},
+		&bytes.Buffer{},
+	)
+	if err == nil || !strings.Contains(err.Error(), "unknown report format") {
+		t.Errorf("err = %v, want an error for the unknown format", err)
+	}
+}
+
+func TestMarkdownEscaper(t *testing.T) {
+	if got := markdownEscaper.Replace("[Dune](x) *1*"); got != `\[Dune\](x) \*1\*` {
+		t.Errorf("escaped %q", got)
+	}
+}
@@ -0,0 +1,39 @@
+// Package server routes every function of the api directory on a single
+// http.ServeMux, under the same paths as the Vercel deployment, for
+// platforms that run one process for all of them.
+package server
+
+import (
+	"net/http"
+
+	"humblebundle-scraper/api/docs"
+	"humblebundle-scraper/api/health"
+	"humblebundle-scraper/api/humblebundle"
+	"humblebundle-scraper/api/usage"
+	"humblebundle-scraper/api/woksoflife"
+	"humblebundle-scraper/internal"
+)
+
+func NewMux(app *internal.App) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/humblebundle/md", humblebundle.NewHandler(app))
+	mux.HandleFunc("/api/humblebundle/list", humblebundle.NewListHandler(app))
+	mux.HandleFunc("/api/humblebundle/feed", humblebundle.NewFeedHandler(app))
+	mux.HandleFunc("/api/woksoflife/md", woksoflife.NewHandler(app))
+
+	// mirror the rewrites of vercel.json
+	mux.HandleFunc("/api/docs/openapi", docs.OpenAPIHandler)
+	mux.HandleFunc("/openapi.json", docs.OpenAPIHandler)
+	mux.HandleFunc("/api/docs/ui", docs.UIHandler)
+	mux.HandleFunc("/docs", docs.UIHandler)
+	mux.HandleFunc("/api/health/healthz", health.HealthzHandler)
+	mux.HandleFunc("/healthz", health.HealthzHandler)
+	mux.HandleFunc("/api/health/readyz", health.NewReadyzHandler(app))
+	mux.HandleFunc("/readyz", health.NewReadyzHandler(app))
+	mux.HandleFunc("/api/usage/browserless", usage.NewBrowserlessHandler(app))
+	mux.HandleFunc("/usage", usage.NewBrowserlessHandler(app))
+	mux.HandleFunc("/feed", humblebundle.NewFeedHandler(app))
+
+	return mux
+}